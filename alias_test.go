@@ -0,0 +1,110 @@
+package envx
+
+import (
+	"testing"
+)
+
+func TestProcessWithAliases(t *testing.T) {
+	type Config struct {
+		Host string `envx:"DB_HOST" aliases:"OLD_DB_HOST, LEGACY_HOST"`
+	}
+
+	tests := []struct {
+		name      string
+		source    map[string]string
+		want      string
+		wantAlias string
+	}{
+		{
+			name:   "primary wins",
+			source: map[string]string{"APP_DB_HOST": "new", "OLD_DB_HOST": "old", "LEGACY_HOST": "legacy"},
+			want:   "new",
+		},
+		{
+			name:      "first alias",
+			source:    map[string]string{"OLD_DB_HOST": "old", "LEGACY_HOST": "legacy"},
+			want:      "old",
+			wantAlias: "OLD_DB_HOST",
+		},
+		{
+			name:      "second alias",
+			source:    map[string]string{"LEGACY_HOST": "legacy"},
+			want:      "legacy",
+			wantAlias: "LEGACY_HOST",
+		},
+		{
+			name:   "alias is not prefixed",
+			source: map[string]string{"APP_OLD_DB_HOST": "old"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warned []string
+			var prov Provenance
+			config := &Config{}
+			err := ProcessWith("APP", config,
+				WithLookuper(MapSource(tt.source)),
+				WithAliasWarning(func(alias, key string) {
+					warned = append(warned, alias+"->"+key)
+				}),
+				WithProvenance(&prov),
+			)
+			if err != nil {
+				t.Fatalf("ProcessWith() unexpected error: %v", err)
+			}
+
+			if config.Host != tt.want {
+				t.Errorf("Expected Host %q, got %q", tt.want, config.Host)
+			}
+			if tt.wantAlias == "" {
+				if len(warned) != 0 {
+					t.Errorf("Expected no alias warning, got %v", warned)
+				}
+				return
+			}
+			if len(warned) != 1 || warned[0] != tt.wantAlias+"->APP_DB_HOST" {
+				t.Errorf("Expected warning for %s, got %v", tt.wantAlias, warned)
+			}
+			if got := prov["Host"]; got.Kind != FromAlias || got.Key != tt.wantAlias {
+				t.Errorf("Provenance[Host] = %+v", got)
+			}
+		})
+	}
+}
+
+func TestProcessWithStrict(t *testing.T) {
+	type Config struct {
+		Database struct {
+			Host string `envx:"HOST_PRIMARY"`
+		} `envx:"DB"`
+	}
+	src := MapSource(map[string]string{"DB_HOST": "unrelated"})
+
+	config := &Config{}
+	if err := ProcessWith("", config, WithLookuper(src)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Database.Host != "unrelated" {
+		t.Errorf("Expected truncation fallback by default, got %q", config.Database.Host)
+	}
+
+	config = &Config{}
+	if err := ProcessWith("", config, WithLookuper(src), Strict()); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Database.Host != "" {
+		t.Errorf("Expected no fallback in strict mode, got %q", config.Database.Host)
+	}
+}
+
+func TestCheckDisallowedAliases(t *testing.T) {
+	type Config struct {
+		Host string `envx:"HOST" aliases:"APP_SERVER"`
+	}
+	src := MapSource(map[string]string{"APP_SERVER": "x"})
+	if err := CheckDisallowed("APP", &Config{}, WithLookuper(src)); err != nil {
+		t.Errorf("CheckDisallowed() unexpected error: %v", err)
+	}
+}
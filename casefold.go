@@ -0,0 +1,101 @@
+package envx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrAmbiguousKey = errors.New("ambiguous key")
+
+// CaseInsensitive makes Process and CheckDisallowed match keys regardless of
+// case on every platform, as the Windows environment always does. Keys that
+// only differ by case, such as APP_HOST and app_host, are reported as
+// ErrAmbiguousKey when the spec reads them.
+func CaseInsensitive() Option {
+	return func(o *options) {
+		o.caseInsensitive = true
+	}
+}
+
+type foldSource struct {
+	Lookuper
+	// index maps upper-cased keys to the keys the source holds. It is nil
+	// when the source cannot list its keys.
+	index map[string][]string
+}
+
+func newFoldSource(l Lookuper) foldSource {
+	f := foldSource{Lookuper: l}
+	lister, ok := l.(KeyLister)
+	if !ok {
+		return f
+	}
+	f.index = make(map[string][]string)
+	for _, k := range lister.Keys() {
+		folded := strings.ToUpper(k)
+		if !containsString(f.index[folded], k) {
+			f.index[folded] = append(f.index[folded], k)
+		}
+	}
+	return f
+}
+
+// resolve returns the key l holds for key.
+func (f foldSource) resolve(key string) (string, bool) {
+	if _, ok := f.Lookuper.Lookup(key); ok {
+		return key, true
+	}
+	candidates := f.index[strings.ToUpper(key)]
+	if f.index == nil {
+		candidates = []string{strings.ToUpper(key), strings.ToLower(key)}
+	}
+	for _, k := range candidates {
+		if _, ok := f.Lookuper.Lookup(k); ok {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func (f foldSource) Lookup(key string) (string, bool) {
+	k, ok := f.resolve(key)
+	if !ok {
+		return "", false
+	}
+	return f.Lookuper.Lookup(k)
+}
+
+func (f foldSource) Keys() []string {
+	if lister, ok := f.Lookuper.(KeyLister); ok {
+		return lister.Keys()
+	}
+	return nil
+}
+
+func (f foldSource) locate(o Origin) Origin {
+	if k, ok := f.resolve(o.Key); ok {
+		o.Key = k
+	}
+	return locate(f.Lookuper, o)
+}
+
+// checkAmbiguous reports keys of f that only differ from key by case.
+func (f foldSource) checkAmbiguous(key string) error {
+	if key == "" {
+		return nil
+	}
+	if keys := f.index[strings.ToUpper(key)]; len(keys) > 1 {
+		return fmt.Errorf("envx: %w: %s matches %s", ErrAmbiguousKey, key, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package envx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProcessWithCaseInsensitive(t *testing.T) {
+	type Config struct {
+		Host     string `envx:"HOST"`
+		Port     int    `envx:"PORT"`
+		Database struct {
+			Name string `envx:"NAME_PRIMARY"`
+		} `envx:"DB"`
+	}
+
+	tests := []struct {
+		name   string
+		source Lookuper
+	}{
+		{
+			name: "listable",
+			source: MapSource(map[string]string{
+				"app_host":    "lower",
+				"App_Port":    "8080",
+				"app_db_name": "truncated",
+			}),
+		},
+		{
+			name: "not listable",
+			source: LookuperFunc(func(key string) (string, bool) {
+				v, ok := map[string]string{
+					"app_host":    "lower",
+					"APP_PORT":    "8080",
+					"app_db_name": "truncated",
+				}[key]
+				return v, ok
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prov Provenance
+			config := &Config{}
+			err := ProcessWith("APP", config, WithLookuper(tt.source), CaseInsensitive(), WithProvenance(&prov))
+			if err != nil {
+				t.Fatalf("ProcessWith() unexpected error: %v", err)
+			}
+
+			if config.Host != "lower" || config.Port != 8080 {
+				t.Errorf("ProcessWith() = %+v", config)
+			}
+			if config.Database.Name != "truncated" {
+				t.Errorf("Expected nested fallback to match case-insensitively, got %q", config.Database.Name)
+			}
+			if got := prov["Host"]; got.Key != "app_host" {
+				t.Errorf("Provenance[Host].Key = %q, want %q", got.Key, "app_host")
+			}
+		})
+	}
+
+	config := &Config{}
+	err := ProcessWith("APP", config, WithLookuper(tests[0].source))
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Host != "" {
+		t.Errorf("Expected case-sensitive lookup by default, got %q", config.Host)
+	}
+}
+
+func TestProcessWithCaseInsensitiveAmbiguous(t *testing.T) {
+	src := MapSource(map[string]string{"APP_TEST_STRING": "a", "app_test_string": "b"})
+	err := ProcessWith("APP", &TestConfig{}, WithLookuper(src), CaseInsensitive())
+	if !errors.Is(err, ErrAmbiguousKey) {
+		t.Errorf("ProcessWith() expected ErrAmbiguousKey, got %v", err)
+	}
+}
+
+func TestCheckDisallowedCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  map[string]string
+		wantErr error
+		anyErr  bool
+	}{
+		{
+			name:   "known in other case",
+			source: map[string]string{"app_test_string": "x", "Other": "y"},
+		},
+		{
+			name:   "unknown",
+			source: map[string]string{"app_unknown": "x"},
+			anyErr: true,
+		},
+		{
+			name:    "ambiguous",
+			source:  map[string]string{"APP_TEST_INT": "1", "app_test_int": "2"},
+			wantErr: ErrAmbiguousKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDisallowed("APP", &TestConfig{}, WithLookuper(MapSource(tt.source)), CaseInsensitive())
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("CheckDisallowed() expected %v, got %v", tt.wantErr, err)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Errorf("CheckDisallowed() expected error, got nil")
+				}
+			default:
+				if err != nil {
+					t.Errorf("CheckDisallowed() unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
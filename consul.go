@@ -0,0 +1,177 @@
+package envx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ConsulConfig struct {
+	// Addr defaults to $CONSUL_HTTP_ADDR, then http://127.0.0.1:8500.
+	Addr string
+	// Token defaults to $CONSUL_HTTP_TOKEN.
+	Token string
+	// Prefix is the key path listed, e.g. "app/".
+	Prefix string
+	// WaitTime bounds a blocking query in Watch. Defaults to five minutes.
+	WaitTime time.Duration
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// ConsulSource is a Lookuper over the keys below a prefix in a Consul
+// compatible KV HTTP API. A key such as app/database/host is exposed as
+// APP_DATABASE_HOST. Combine it below the environment with a Loader.
+type ConsulSource struct {
+	cfg ConsulConfig
+
+	mu     sync.RWMutex
+	values map[string]string
+	paths  map[string]string
+	index  uint64
+}
+
+func NewConsulSource(ctx context.Context, cfg ConsulConfig) (*ConsulSource, error) {
+	if cfg.Addr == "" {
+		cfg.Addr, _ = lookupEnv("CONSUL_HTTP_ADDR")
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = "http://127.0.0.1:8500"
+	}
+	if !strings.Contains(cfg.Addr, "://") {
+		cfg.Addr = "http://" + cfg.Addr
+	}
+	cfg.Addr = strings.TrimRight(cfg.Addr, "/")
+	if cfg.Token == "" {
+		cfg.Token, _ = lookupEnv("CONSUL_HTTP_TOKEN")
+	}
+	if cfg.WaitTime == 0 {
+		cfg.WaitTime = 5 * time.Minute
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	c := &ConsulSource{cfg: cfg}
+	if _, err := c.fetch(ctx, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Index returns the Consul index of the data currently held.
+func (c *ConsulSource) Index() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.index
+}
+
+// Watch performs a blocking query that returns once the keys below the
+// prefix change or WaitTime elapses. It reports whether the data changed.
+func (c *ConsulSource) Watch(ctx context.Context) (bool, error) {
+	return c.fetch(ctx, c.Index())
+}
+
+func (c *ConsulSource) fetch(ctx context.Context, index uint64) (bool, error) {
+	q := url.Values{"recurse": {"true"}}
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", fmt.Sprintf("%dms", c.cfg.WaitTime.Milliseconds()))
+	}
+	u := c.cfg.Addr + "/v1/kv/" + strings.TrimLeft(c.cfg.Prefix, "/") + "?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false, err
+	}
+	if c.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", c.cfg.Token)
+	}
+
+	resp, err := c.cfg.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var pairs []struct {
+		Key   string
+		Value []byte
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+			return false, fmt.Errorf("consul: decoding %s: %w", c.cfg.Prefix, err)
+		}
+	case http.StatusNotFound:
+		// No keys below the prefix yet.
+	default:
+		return false, fmt.Errorf("consul: listing %s: %s", c.cfg.Prefix, resp.Status)
+	}
+
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	// Consul may move the index backwards, e.g. after a snapshot restore;
+	// the next query must then start over.
+	if newIndex < index {
+		newIndex = 0
+	}
+
+	values := make(map[string]string, len(pairs))
+	paths := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		if p.Value == nil || strings.HasSuffix(p.Key, "/") {
+			continue
+		}
+		key := kvPathToKey(p.Key)
+		values[key] = string(p.Value)
+		paths[key] = p.Key
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := index == 0 || newIndex != c.index
+	c.values = values
+	c.paths = paths
+	c.index = newIndex
+	return changed, nil
+}
+
+func kvPathToKey(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		segments[i] = FileNameToKey(s)
+	}
+	return strings.Join(segments, "_")
+}
+
+func (c *ConsulSource) Lookup(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.values[key]
+	return v, ok
+}
+
+func (c *ConsulSource) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *ConsulSource) locate(o Origin) Origin {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	o.Source = "consul:" + c.paths[o.Key]
+	return o
+}
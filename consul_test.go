@@ -0,0 +1,189 @@
+package envx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	kv      map[string]string
+	changed chan struct{}
+}
+
+func newFakeConsul(t *testing.T, kv map[string]string) (*fakeConsul, *httptest.Server) {
+	t.Helper()
+	f := &fakeConsul{index: 10, kv: kv, changed: make(chan struct{})}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeConsul) set(key, value string) {
+	f.mu.Lock()
+	f.kv[key] = value
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+	f.mu.Unlock()
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+	f.mu.Lock()
+	changed := f.changed
+	index := f.index
+	f.mu.Unlock()
+
+	if want, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); want != 0 && want == index {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	type pair struct {
+		Key   string
+		Value []byte
+	}
+	pairs := []pair{{Key: prefix}}
+	for k, v := range f.kv {
+		if strings.HasPrefix(k, prefix) {
+			pairs = append(pairs, pair{Key: k, Value: []byte(v)})
+		}
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(pairs) == 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+func TestConsulSource(t *testing.T) {
+	_, srv := newFakeConsul(t, map[string]string{
+		"app/database/host": "kv-host",
+		"app/database/port": "5432",
+		"app/log-level":     "debug",
+		"other/key":         "x",
+	})
+
+	src, err := NewConsulSource(context.Background(), ConsulConfig{
+		Addr:   srv.URL,
+		Token:  "token",
+		Prefix: "app/",
+	})
+	if err != nil {
+		t.Fatalf("NewConsulSource() unexpected error: %v", err)
+	}
+	if src.Index() != 10 {
+		t.Errorf("Expected index 10, got %d", src.Index())
+	}
+
+	keys := src.Keys()
+	want := []string{"APP_DATABASE_HOST", "APP_DATABASE_PORT", "APP_LOG_LEVEL"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("Keys() = %v, want %v", keys, want)
+	}
+
+	type Config struct {
+		Database struct {
+			Host string `envx:"HOST"`
+			Port int    `envx:"PORT"`
+		} `envx:"DATABASE"`
+		LogLevel string `envx:"LOG_LEVEL"`
+	}
+
+	var prov Provenance
+	config := &Config{}
+	err = NewLoader("APP").
+		WithSource("env", MapSource(map[string]string{"APP_DATABASE_HOST": "env-host"})).
+		WithSource("consul", src).
+		WithOptions(WithProvenance(&prov)).
+		Load(config)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if config.Database.Host != "env-host" {
+		t.Errorf("Expected environment to win, got %q", config.Database.Host)
+	}
+	if config.Database.Port != 5432 || config.LogLevel != "debug" {
+		t.Errorf("Load() = %+v", config)
+	}
+	if got := prov["Database.Port"].Source; got != "consul" {
+		t.Errorf("Provenance[Database.Port].Source = %q", got)
+	}
+}
+
+func TestConsulSourceWatch(t *testing.T) {
+	fake, srv := newFakeConsul(t, map[string]string{"app/name": "v1"})
+
+	src, err := NewConsulSource(context.Background(), ConsulConfig{
+		Addr:     srv.URL,
+		Token:    "token",
+		Prefix:   "app",
+		WaitTime: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewConsulSource() unexpected error: %v", err)
+	}
+
+	changed, err := src.Watch(context.Background())
+	if err != nil {
+		t.Fatalf("Watch() unexpected error: %v", err)
+	}
+	if changed {
+		t.Errorf("Expected no change when the wait time elapses")
+	}
+
+	src.cfg.WaitTime = 5 * time.Second
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		fake.set("app/name", "v2")
+	}()
+	changed, err = src.Watch(context.Background())
+	if err != nil {
+		t.Fatalf("Watch() unexpected error: %v", err)
+	}
+	if !changed || src.Index() != 11 {
+		t.Errorf("Expected change at index 11, got changed=%v index=%d", changed, src.Index())
+	}
+	if v, _ := src.Lookup("APP_NAME"); v != "v2" {
+		t.Errorf("Expected updated value 'v2', got %q", v)
+	}
+}
+
+func TestConsulSourceErrors(t *testing.T) {
+	_, srv := newFakeConsul(t, map[string]string{})
+
+	_, err := NewConsulSource(context.Background(), ConsulConfig{Addr: srv.URL, Prefix: "app/"})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected 403 error, got %v", err)
+	}
+
+	src, err := NewConsulSource(context.Background(), ConsulConfig{Addr: srv.URL, Token: "token", Prefix: "empty/"})
+	if err != nil {
+		t.Fatalf("Expected empty prefix to succeed, got %v", err)
+	}
+	if len(src.Keys()) != 0 {
+		t.Errorf("Expected no keys, got %v", src.Keys())
+	}
+}
@@ -0,0 +1,82 @@
+package envx
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrCredentialMissing = errors.New("credential not found")
+
+// CredentialsSource exposes the systemd credentials in
+// $CREDENTIALS_DIRECTORY (see LoadCredential= and SetCredential=) as a
+// Lookuper. Credential names are mapped with FileNameToKey, so db-password
+// is found as DB_PASSWORD.
+func CredentialsSource() (Lookuper, error) {
+	dir, ok := lookupEnv("CREDENTIALS_DIRECTORY")
+	if !ok || dir == "" {
+		return nil, fmt.Errorf("envx: %w: CREDENTIALS_DIRECTORY is not set", ErrCredentialMissing)
+	}
+	return DirSource(dir, DirKeyMapper(FileNameToKey))
+}
+
+// WithCredentials makes Process read systemd credentials before any other
+// source. A field is matched by its key, or by the credential name given in
+// its `credential` tag. Required fields with a `credential` tag fail with
+// ErrCredentialMissing when the credential is absent.
+func WithCredentials() Option {
+	return func(o *options) {
+		o.credentials = true
+	}
+}
+
+type credentialStore struct {
+	dir string
+	src Lookuper
+}
+
+func newCredentialStore() (*credentialStore, error) {
+	c := &credentialStore{}
+	dir, ok := lookupEnv("CREDENTIALS_DIRECTORY")
+	if !ok || dir == "" {
+		return c, nil
+	}
+	src, err := DirSource(dir)
+	if err != nil {
+		return nil, fmt.Errorf("envx: reading credentials: %w", err)
+	}
+	mapped, err := DirSource(dir, DirKeyMapper(FileNameToKey))
+	if err != nil {
+		return nil, fmt.Errorf("envx: reading credentials: %w", err)
+	}
+	c.dir = dir
+	c.src = MultiSource(src, mapped)
+	return c, nil
+}
+
+func (c *credentialStore) lookup(info varInfo) (string, Origin, bool) {
+	if c.src == nil {
+		return "", Origin{}, false
+	}
+	name := info.Opts.Credential
+	keys := []string{name}
+	if name == "" {
+		keys = []string{info.Key, info.Alt}
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if v, ok := c.src.Lookup(key); ok {
+			file := locate(c.src, Origin{Key: key}).File
+			return v, Origin{Key: info.Key, Kind: FromKey, Source: "credentials", File: file}, true
+		}
+	}
+	return "", Origin{}, false
+}
+
+func (c *credentialStore) missing(name string) error {
+	if c.dir == "" {
+		return fmt.Errorf("envx: required credential %s: %w: CREDENTIALS_DIRECTORY is not set", name, ErrCredentialMissing)
+	}
+	return fmt.Errorf("envx: required credential %s: %w in %s", name, ErrCredentialMissing, c.dir)
+}
@@ -0,0 +1,97 @@
+package envx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProcessWithCredentials(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, "db-password", "s3cret\n", 0o400)
+	writeSecret(t, dir, "api_token", "tok", 0o400)
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	type Config struct {
+		Password string `envx:"DB_PASSWORD"`
+		Token    string `credential:"api_token"`
+		User     string `envx:"DB_USER"`
+	}
+
+	var prov Provenance
+	config := &Config{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{
+			"DB_PASSWORD": "from env",
+			"DB_USER":     "admin",
+		})),
+		WithCredentials(),
+		WithProvenance(&prov),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if config.Password != "s3cret" {
+		t.Errorf("Expected credential to win over env, got %q", config.Password)
+	}
+	if config.Token != "tok" {
+		t.Errorf("Expected Token 'tok', got %q", config.Token)
+	}
+	if config.User != "admin" {
+		t.Errorf("Expected User 'admin', got %q", config.User)
+	}
+	if got := prov["Password"]; got.Source != "credentials" || got.File == "" {
+		t.Errorf("Provenance[Password] = %+v", got)
+	}
+	if got := prov["User"]; got.Source != "map" {
+		t.Errorf("Provenance[User] = %+v", got)
+	}
+}
+
+func TestProcessWithCredentialsMissing(t *testing.T) {
+	type Config struct {
+		Password string `credential:"db-password" required:"true"`
+	}
+
+	tests := []struct {
+		name string
+		dir  bool
+	}{
+		{name: "absent file", dir: true},
+		{name: "no directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := ""
+			if tt.dir {
+				dir = t.TempDir()
+			}
+			t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+			err := ProcessWith("", &Config{}, WithLookuper(MapSource(nil)), WithCredentials())
+			if !errors.Is(err, ErrCredentialMissing) {
+				t.Errorf("ProcessWith() expected ErrCredentialMissing, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCredentialsSource(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, "db.host", "localhost", 0o400)
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	src, err := CredentialsSource()
+	if err != nil {
+		t.Fatalf("CredentialsSource() unexpected error: %v", err)
+	}
+	if v, ok := src.Lookup("DB_HOST"); !ok || v != "localhost" {
+		t.Errorf("Lookup(DB_HOST) = %q, %v", v, ok)
+	}
+
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+	if _, err := CredentialsSource(); !errors.Is(err, ErrCredentialMissing) {
+		t.Errorf("CredentialsSource() expected ErrCredentialMissing, got %v", err)
+	}
+}
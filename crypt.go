@@ -0,0 +1,205 @@
+package envx
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EncPrefix marks an encrypted value, as in "enc:v1:<base64>".
+const EncPrefix = "enc:v1:"
+
+const keyIDLen = 4
+
+var (
+	ErrNoKey        = errors.New("no decryption key configured")
+	ErrUnknownKeyID = errors.New("value was encrypted with an unknown key")
+	ErrMalformedEnc = errors.New("malformed encrypted value")
+)
+
+type DecryptError struct {
+	KeyName   string
+	FieldName string
+	Err       error
+}
+
+func (e *DecryptError) Error() string {
+	return fmt.Sprintf("envx.Process: decrypting %[1]s for %[2]s: %[3]s", e.KeyName, e.FieldName, e.Err)
+}
+
+func (e *DecryptError) Unwrap() error {
+	return e.Err
+}
+
+type keyringKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Keyring holds AES-256-GCM keys. The first key encrypts; every key can
+// decrypt, so keys can be rotated by prepending a new one.
+type Keyring struct {
+	keys []keyringKey
+}
+
+// GenerateKey returns a new random 32 byte key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+	k := &Keyring{}
+	for _, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("envx: key must be 32 bytes, got %d", len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys = append(k.keys, keyringKey{id: KeyID(key), aead: aead})
+	}
+	return k, nil
+}
+
+// KeyID returns the identifier stored alongside values encrypted with key.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:keyIDLen])
+}
+
+// KeyringFromEnv builds a Keyring from base64 keys in $ENVX_KEY (comma
+// separated) or, if unset, from $ENVX_KEY_FILE (one key per line).
+func KeyringFromEnv() (*Keyring, error) {
+	var encoded []string
+	if v, ok := lookupEnv("ENVX_KEY"); ok && v != "" {
+		encoded = strings.Split(v, ",")
+	} else if path, ok := lookupEnv("ENVX_KEY_FILE"); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			encoded = append(encoded, sc.Text())
+		}
+	}
+
+	var keys [][]byte
+	for _, e := range encoded {
+		e = strings.TrimSpace(e)
+		if e == "" || strings.HasPrefix(e, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(e)
+		if err != nil {
+			return nil, fmt.Errorf("envx: decoding key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys...)
+}
+
+// Encrypt returns plaintext encrypted with the first key, prefixed with
+// EncPrefix.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	key := k.keys[0]
+	id, _ := hex.DecodeString(key.id)
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	blob := append(id, nonce...)
+	blob = key.aead.Seal(blob, nonce, []byte(plaintext), id)
+	return EncPrefix + base64.StdEncoding.EncodeToString(blob), nil
+}
+
+// Decrypt reverses Encrypt. Values without EncPrefix are returned as is.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, EncPrefix)
+	if !ok {
+		return value, nil
+	}
+	blob, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMalformedEnc, err)
+	}
+	if len(blob) < keyIDLen {
+		return "", ErrMalformedEnc
+	}
+
+	id := hex.EncodeToString(blob[:keyIDLen])
+	for _, key := range k.keys {
+		if key.id != id {
+			continue
+		}
+		rest := blob[keyIDLen:]
+		if len(rest) < key.aead.NonceSize() {
+			return "", ErrMalformedEnc
+		}
+		nonce, ciphertext := rest[:key.aead.NonceSize()], rest[key.aead.NonceSize():]
+		plaintext, err := key.aead.Open(nil, nonce, ciphertext, blob[:keyIDLen])
+		if err != nil {
+			return "", fmt.Errorf("key %s: %w", id, err)
+		}
+		return string(plaintext), nil
+	}
+	return "", fmt.Errorf("%w %s", ErrUnknownKeyID, id)
+}
+
+// WithDecryption makes Process decrypt values starting with EncPrefix using
+// the keys from KeyringFromEnv.
+func WithDecryption() Option {
+	return func(o *options) {
+		o.decrypt = true
+	}
+}
+
+// WithKeyring makes Process decrypt values starting with EncPrefix using k.
+func WithKeyring(k *Keyring) Option {
+	return func(o *options) {
+		o.decrypt = true
+		o.keyring = k
+	}
+}
+
+// decrypter loads the keyring on first use so specs without encrypted
+// values do not require a key.
+type decrypter struct {
+	keyring *Keyring
+	err     error
+}
+
+func (d *decrypter) decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, EncPrefix) {
+		return value, nil
+	}
+	if d.keyring == nil && d.err == nil {
+		d.keyring, d.err = KeyringFromEnv()
+	}
+	if d.err != nil {
+		return "", d.err
+	}
+	return d.keyring.Decrypt(value)
+}
@@ -0,0 +1,191 @@
+package envx
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestKeyring(t *testing.T) (*Keyring, []byte) {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKeyring(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k, key
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	k, _ := newTestKeyring(t)
+
+	enc, err := k.Encrypt("s3cret")
+	if err != nil {
+		t.Fatalf("Encrypt() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(enc, EncPrefix) {
+		t.Errorf("Encrypt() = %q, want %s prefix", enc, EncPrefix)
+	}
+	other, _ := k.Encrypt("s3cret")
+	if other == enc {
+		t.Errorf("Encrypt() should use a fresh nonce")
+	}
+
+	got, err := k.Decrypt(enc)
+	if err != nil {
+		t.Fatalf("Decrypt() unexpected error: %v", err)
+	}
+	if got != "s3cret" {
+		t.Errorf("Decrypt() = %q, want 's3cret'", got)
+	}
+
+	if got, _ := k.Decrypt("plain"); got != "plain" {
+		t.Errorf("Decrypt() should pass plain values through, got %q", got)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldRing, oldKey := newTestKeyring(t)
+	enc, err := oldRing.Encrypt("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey, _ := GenerateKey()
+	rotated, err := NewKeyring(newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := rotated.Decrypt(enc); err != nil || got != "legacy" {
+		t.Errorf("Decrypt() with rotated keyring = %q, %v", got, err)
+	}
+
+	fresh, _ := rotated.Encrypt("fresh")
+	if _, err := oldRing.Decrypt(fresh); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("Expected ErrUnknownKeyID, got %v", err)
+	}
+}
+
+func TestKeyringErrors(t *testing.T) {
+	if _, err := NewKeyring(); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+	if _, err := NewKeyring([]byte("short")); err == nil {
+		t.Errorf("Expected error for short key")
+	}
+
+	k, _ := newTestKeyring(t)
+	enc, _ := k.Encrypt("value")
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(enc, EncPrefix))
+	raw[len(raw)-1] ^= 0xff
+	tampered := EncPrefix + base64.StdEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name  string
+		value string
+		want  error
+	}{
+		{"bad base64", EncPrefix + "!!!", ErrMalformedEnc},
+		{"too short", EncPrefix + "AAE=", ErrMalformedEnc},
+		{"tampered", tampered, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := k.Decrypt(tt.value)
+			if err == nil {
+				t.Fatalf("Decrypt() expected error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt() expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestProcessDecryptsValues(t *testing.T) {
+	k, key := newTestKeyring(t)
+	pw, _ := k.Encrypt("s3cret")
+	port, _ := k.Encrypt("5432")
+
+	type Config struct {
+		Password string `envx:"DB_PASSWORD"`
+		Port     int    `envx:"DB_PORT"`
+		Host     string `envx:"DB_HOST"`
+	}
+	env := map[string]string{"DB_PASSWORD": pw, "DB_PORT": port, "DB_HOST": "plain"}
+
+	config := &Config{}
+	if err := ProcessWith("", config, WithLookuper(MapSource(env)), WithKeyring(k)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Password != "s3cret" || config.Port != 5432 || config.Host != "plain" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+
+	t.Run("key from ENVX_KEY", func(t *testing.T) {
+		other, _ := GenerateKey()
+		t.Setenv("ENVX_KEY", base64.StdEncoding.EncodeToString(other)+","+base64.StdEncoding.EncodeToString(key))
+		config := &Config{}
+		if err := ProcessWith("", config, WithLookuper(MapSource(env)), WithDecryption()); err != nil {
+			t.Fatalf("ProcessWith() unexpected error: %v", err)
+		}
+		if config.Password != "s3cret" {
+			t.Errorf("Expected Password 's3cret', got %q", config.Password)
+		}
+	})
+
+	t.Run("key from ENVX_KEY_FILE", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys")
+		content := "# current\n" + base64.StdEncoding.EncodeToString(key) + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("ENVX_KEY", "")
+		t.Setenv("ENVX_KEY_FILE", path)
+		config := &Config{}
+		if err := ProcessWith("", config, WithLookuper(MapSource(env)), WithDecryption()); err != nil {
+			t.Fatalf("ProcessWith() unexpected error: %v", err)
+		}
+		if config.Port != 5432 {
+			t.Errorf("Expected Port 5432, got %d", config.Port)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		t.Setenv("ENVX_KEY", "")
+		t.Setenv("ENVX_KEY_FILE", "")
+		err := ProcessWith("", &Config{}, WithLookuper(MapSource(env)), WithDecryption())
+		var derr *DecryptError
+		if !errors.As(err, &derr) || !errors.Is(err, ErrNoKey) {
+			t.Errorf("Expected DecryptError wrapping ErrNoKey, got %v", err)
+		}
+	})
+
+	t.Run("wrong key is not a parse error", func(t *testing.T) {
+		wrong, _ := newTestKeyring(t)
+		err := ProcessWith("", &Config{}, WithLookuper(MapSource(env)), WithKeyring(wrong))
+		var derr *DecryptError
+		if !errors.As(err, &derr) || derr.KeyName != "DB_PASSWORD" {
+			t.Fatalf("Expected DecryptError on DB_PASSWORD, got %v", err)
+		}
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			t.Errorf("Decryption failure must not be a ParseError")
+		}
+	})
+
+	t.Run("no key needed without encrypted values", func(t *testing.T) {
+		t.Setenv("ENVX_KEY", "")
+		t.Setenv("ENVX_KEY_FILE", "")
+		err := ProcessWith("", &Config{}, WithLookuper(MapSource(map[string]string{"DB_HOST": "x"})), WithDecryption())
+		if err != nil {
+			t.Errorf("ProcessWith() unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package envx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dialect selects the struct tags Process reads and how keys are derived
+// from them, so structs written for other packages can be processed
+// unchanged.
+type Dialect int
+
+const (
+	// DialectEnvx reads the envx tags described in the package
+	// documentation. It is the default.
+	DialectEnvx Dialect = iota
+	// DialectEnvconfig reads github.com/kelseyhightower/envconfig tags:
+	// `envconfig:"NAME"` names a key, which is looked up with and without
+	// the prefix, and `default`, `required`, `ignored`, `split_words` and
+	// `desc` work as in envx. Keys are never truncated to shorter ones.
+	DialectEnvconfig
+	// DialectEnv reads github.com/caarlos0/env tags: `env:"KEY,options"`,
+	// `envDefault`, `envSeparator`, `envKeyValSeparator` and `envPrefix`.
+	// Keys are used exactly as written and `envPrefix` values are
+	// concatenated without a separator, as in caarlos0/env. The prefix given
+	// to Process is also used as written, followed by "_" unless it already
+	// ends with one. Fields without an `env` tag are skipped unless they are
+	// structs. The options required, notEmpty, file, expand and unset are
+	// supported.
+	DialectEnv
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case DialectEnvx:
+		return "envx"
+	case DialectEnvconfig:
+		return "envconfig"
+	case DialectEnv:
+		return "env"
+	}
+	return fmt.Sprintf("Dialect(%d)", int(d))
+}
+
+// WithDialect makes Process read the struct tags of dialect d.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.naming.dialect = d
+	}
+}
+
+// parseEnvTags reads the tags of DialectEnv.
+func parseEnvTags(tag reflect.StructTag) (fieldTags, error) {
+	t := fieldTags{Sep: ",", KVSep: ":"}
+
+	name, opts := splitTagOptions(tag.Get("env"))
+	if name == "-" {
+		t.Ignored = true
+		return t, nil
+	}
+	t.Name = name
+	for _, opt := range opts {
+		switch strings.TrimSpace(opt) {
+		case "required":
+			t.Required = true
+		case "notEmpty":
+			t.Required = true
+			t.NotEmpty = true
+		case "file":
+			t.File = true
+		case "expand":
+			t.Expand = true
+		case "unset":
+			t.Unset = true
+		case "init":
+			// Nil struct pointers are always initialized.
+		default:
+			return t, fmt.Errorf("%w: unknown option %q in env tag", ErrInvalidTag, opt)
+		}
+	}
+
+	t.Default = tag.Get("envDefault")
+	t.Prefix = tag.Get("envPrefix")
+	if sep, ok := tag.Lookup("envSeparator"); ok && sep != "" {
+		t.Sep = sep
+	}
+	if sep, ok := tag.Lookup("envKeyValSeparator"); ok && sep != "" {
+		t.KVSep = sep
+	}
+	return t, nil
+}
@@ -0,0 +1,219 @@
+package envx
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessDialectEnvconfig(t *testing.T) {
+	type Database struct {
+		Host string `envconfig:"HOST" default:"localhost"`
+		Port int
+	}
+	type Config struct {
+		Debug        bool
+		Port         int           `envconfig:"PORT"`
+		User         string        `split_words:"true"`
+		MultiWordVar string        `envconfig:"multi_word" split_words:"true"`
+		Rate         float64       `envconfig:"RATE" required:"true"`
+		Skipped      string        `ignored:"true"`
+		Timeout      time.Duration `envconfig:"TIMEOUT" default:"5s"`
+		DB           Database      `envconfig:"DB"`
+		Name         string        `envx:"IGNORED_IN_THIS_DIALECT"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("MYAPP", config,
+		WithDialect(DialectEnvconfig),
+		WithLookuper(MapSource(map[string]string{
+			"MYAPP_DEBUG":      "true",
+			"PORT":             "8080",
+			"MYAPP_USER":       "kelsey",
+			"MYAPP_MULTI_WORD": "words",
+			"MYAPP_RATE":       "0.5",
+			"MYAPP_SKIPPED":    "nope",
+			"MYAPP_DB_PORT":    "5432",
+			"MYAPP_NAME":       "name",
+		})),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if !config.Debug || config.Port != 8080 || config.User != "kelsey" || config.MultiWordVar != "words" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+	if config.Rate != 0.5 || config.Skipped != "" || config.Timeout != 5*time.Second {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+	if config.DB.Host != "localhost" || config.DB.Port != 5432 {
+		t.Errorf("Expected DB from MYAPP_DB_*, got %+v", config.DB)
+	}
+	if config.Name != "name" {
+		t.Errorf("Expected envx tag to be ignored, got Name %q", config.Name)
+	}
+}
+
+func TestProcessDialectEnvconfigNoTruncation(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host string
+		} `envconfig:"DB"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("APP", config,
+		WithDialect(DialectEnvconfig),
+		WithLookuper(MapSource(map[string]string{"APP_DB": "oops"})),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.DB.Host != "" {
+		t.Errorf("Expected APP_DB to be ignored for DB.Host, got %q", config.DB.Host)
+	}
+}
+
+func TestProcessDialectEnv(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST" envDefault:"localhost"`
+		Port int    `env:"PORT,required"`
+	}
+	type Config struct {
+		Home     string            `env:"HOME_DIR"`
+		Hosts    []string          `env:"HOSTS" envSeparator:";"`
+		Labels   map[string]string `env:"LABELS" envKeyValSeparator:"="`
+		URL      string            `env:"URL,expand"`
+		Token    string            `env:"TOKEN,unset"`
+		Untagged string
+		Primary  Database  `envPrefix:"PG_"`
+		Replica  *Database `envPrefix:"REPLICA_"`
+		Skipped  string    `env:"-"`
+	}
+
+	t.Setenv("APP_TOKEN", "secret")
+	src := MultiSource(MapSource(map[string]string{
+		"APP_HOME_DIR":     "/home/app",
+		"APP_HOSTS":        "a,1;b,2",
+		"APP_LABELS":       "team=core,tier=1",
+		"APP_URL":          "http://${APP_PG_HOST}:${APP_PG_PORT}",
+		"APP_UNTAGGED":     "ignored",
+		"APP_PG_HOST":      "db",
+		"APP_PG_PORT":      "5432",
+		"APP_REPLICA_PORT": "5433",
+		"APP_SKIPPED":      "x",
+		"APP_PRIMARY_HOST": "wrong",
+	}), OSSource())
+
+	config := &Config{}
+	if err := ProcessWith("APP", config, WithDialect(DialectEnv), WithLookuper(src)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if config.Home != "/home/app" || config.Untagged != "" || config.Skipped != "" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+	if strings.Join(config.Hosts, "|") != "a,1|b,2" {
+		t.Errorf("Expected Hosts split on ';', got %q", config.Hosts)
+	}
+	if config.Labels["team"] != "core" || config.Labels["tier"] != "1" {
+		t.Errorf("Expected Labels split on '=', got %v", config.Labels)
+	}
+	if config.URL != "http://db:5432" {
+		t.Errorf("Expected URL expanded, got %q", config.URL)
+	}
+	if config.Primary.Host != "db" || config.Primary.Port != 5432 {
+		t.Errorf("Expected Primary from APP_PG_*, got %+v", config.Primary)
+	}
+	if config.Replica == nil || config.Replica.Host != "localhost" || config.Replica.Port != 5433 {
+		t.Errorf("Expected Replica from APP_REPLICA_*, got %+v", config.Replica)
+	}
+	if config.Token != "secret" {
+		t.Errorf("Expected Token 'secret', got %q", config.Token)
+	}
+	if _, ok := os.LookupEnv("APP_TOKEN"); ok {
+		t.Errorf("Expected APP_TOKEN to be unset")
+	}
+}
+
+func TestProcessDialectEnvErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   any
+		source map[string]string
+		want   string
+	}{
+		{
+			name: "required",
+			spec: &struct {
+				Port int `env:"PORT,required"`
+			}{},
+			want: "required key PORT missing value",
+		},
+		{
+			name: "not empty",
+			spec: &struct {
+				Name string `env:"NAME,notEmpty"`
+			}{},
+			source: map[string]string{"NAME": ""},
+			want:   "required key NAME is empty",
+		},
+		{
+			name: "unknown option",
+			spec: &struct {
+				Name string `env:"NAME,requird"`
+			}{},
+			want: "unknown option",
+		},
+		{
+			name: "no truncation fallback",
+			spec: &struct {
+				Host string `env:"DB_HOST_PRIMARY,required"`
+			}{},
+			source: map[string]string{"DB_HOST": "x"},
+			want:   "required key DB_HOST_PRIMARY missing value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProcessWith("", tt.spec, WithDialect(DialectEnv), WithLookuper(MapSource(tt.source)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ProcessWith() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDisallowedDialects(t *testing.T) {
+	type Config struct {
+		Port int `envx:"PORT" env:"PORT"`
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		dialect Dialect
+	}{
+		{"envx", "APP", DialectEnvx},
+		{"env", "APP", DialectEnv},
+		{"env with trailing underscore", "APP_", DialectEnv},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clean := MapSource(map[string]string{"APP_PORT": "1", "OTHER": "3"})
+			if err := CheckDisallowed(tt.prefix, &Config{}, WithLookuper(clean), WithDialect(tt.dialect)); err != nil {
+				t.Errorf("CheckDisallowed() unexpected error: %v", err)
+			}
+
+			typo := MapSource(map[string]string{"APP_PORT": "1", "APP_PROT": "2"})
+			err := CheckDisallowed(tt.prefix, &Config{}, WithLookuper(typo), WithDialect(tt.dialect))
+			if err == nil || !strings.Contains(err.Error(), "APP_PROT") {
+				t.Errorf("CheckDisallowed() expected APP_PROT to be reported, got %v", err)
+			}
+		})
+	}
+}
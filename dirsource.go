@@ -0,0 +1,96 @@
+package envx
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type dirSource struct {
+	dir    string
+	mapper func(string) string
+	values map[string]string
+	files  map[string]string
+}
+
+type DirOption func(*dirSource)
+
+// DirKeyMapper maps every file name through fn to obtain its key.
+func DirKeyMapper(fn func(name string) string) DirOption {
+	return func(d *dirSource) {
+		d.mapper = fn
+	}
+}
+
+// FileNameToKey turns a file name such as "db.host" or "db-host" into an
+// envx key ("DB_HOST").
+func FileNameToKey(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+// DirSource returns a Lookuper where each file in dir is a key and its
+// trimmed contents are the value, as with Kubernetes ConfigMap and Secret
+// volume mounts. Entries starting with ".." and subdirectories are ignored.
+func DirSource(dir string, opts ...DirOption) (Lookuper, error) {
+	d := &dirSource{
+		dir:    dir,
+		values: make(map[string]string),
+		files:  make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if fi.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key := name
+		if d.mapper != nil {
+			key = d.mapper(name)
+		}
+		d.values[key] = strings.TrimSpace(string(data))
+		d.files[key] = path
+	}
+	return d, nil
+}
+
+func (d *dirSource) Lookup(key string) (string, bool) {
+	v, ok := d.values[key]
+	return v, ok
+}
+
+func (d *dirSource) Keys() []string {
+	keys := make([]string, 0, len(d.values))
+	for k := range d.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (d *dirSource) locate(o Origin) Origin {
+	o.Source = d.dir
+	o.File = d.files[o.Key]
+	return o
+}
@@ -0,0 +1,115 @@
+package envx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mountDir lays out files the way the kubelet does: the real files live in
+// a timestamped directory, "..data" points at it and each key is a symlink
+// through "..data".
+func mountDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	ts := filepath.Join(dir, "..2024_01_01_00_00_00.000000000")
+	if err := os.Mkdir(ts, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ts, files)
+	if err := os.Symlink(filepath.Base(ts), filepath.Join(dir, "..data")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	for name := range files {
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDirSource(t *testing.T) {
+	dir := mountDir(t, map[string]string{
+		"APP_TEST_STRING": "hello\n",
+		"APP_TEST_INT":    "42",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := DirSource(dir)
+	if err != nil {
+		t.Fatalf("DirSource() unexpected error: %v", err)
+	}
+
+	keys := src.(KeyLister).Keys()
+	if len(keys) != 2 || keys[0] != "APP_TEST_INT" || keys[1] != "APP_TEST_STRING" {
+		t.Errorf("Keys() = %v", keys)
+	}
+
+	var prov Provenance
+	config := &TestConfig{}
+	if err := ProcessWith("APP", config, WithLookuper(src), WithProvenance(&prov)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.StringField != "hello" || config.IntField != 42 {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+	if got := prov["IntField"]; got.File != filepath.Join(dir, "APP_TEST_INT") {
+		t.Errorf("Provenance[IntField] = %+v", got)
+	}
+
+	if err := CheckDisallowed("APP", config, WithLookuper(src)); err != nil {
+		t.Errorf("CheckDisallowed() unexpected error: %v", err)
+	}
+}
+
+func TestDirSourceKeyMapper(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db.host":     "localhost",
+		"db-port":     "5432",
+		"db.password": "secret\n",
+	})
+
+	src, err := DirSource(dir, DirKeyMapper(FileNameToKey))
+	if err != nil {
+		t.Fatalf("DirSource() unexpected error: %v", err)
+	}
+
+	type Config struct {
+		Host     string `envx:"HOST"`
+		Port     int    `envx:"PORT"`
+		Password string `envx:"PASSWORD"`
+	}
+	config := &Config{}
+	if err := ProcessWith("DB", config, WithLookuper(src)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Host != "localhost" || config.Port != 5432 || config.Password != "secret" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+}
+
+func TestDirSourceMissing(t *testing.T) {
+	if _, err := DirSource(filepath.Join(t.TempDir(), "nope")); err == nil {
+		t.Errorf("DirSource() expected error for missing directory")
+	}
+}
+
+func TestFileNameToKey(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"db.host", "DB_HOST"},
+		{"db-host", "DB_HOST"},
+		{"DB_HOST", "DB_HOST"},
+		{"log.level-name", "LOG_LEVEL_NAME"},
+	}
+	for _, tt := range tests {
+		if got := FileNameToKey(tt.input); got != tt.want {
+			t.Errorf("FileNameToKey(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
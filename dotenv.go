@@ -0,0 +1,397 @@
+package envx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type DotenvError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *DotenvError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("envx: dotenv line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("envx: %s:%d: %s", e.File, e.Line, e.Err)
+}
+
+func (e *DotenvError) Unwrap() error {
+	return e.Err
+}
+
+type dotenvEntry struct {
+	key   string
+	value string
+	line  int
+}
+
+// ParseDotenv reads dotenv formatted data from r. References to variables
+// that are not defined earlier in r are expanded from the process
+// environment.
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	entries, err := parseDotenv("", r, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(entries))
+	for _, e := range entries {
+		m[e.key] = e.value
+	}
+	return m, nil
+}
+
+// LoadFile reads the named dotenv files and sets every variable that is not
+// already present in the process environment. Earlier files win over later
+// ones.
+func LoadFile(filenames ...string) error {
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+	for _, filename := range filenames {
+		entries, err := readDotenvFile(filename)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if _, ok := os.LookupEnv(e.key); ok {
+				continue
+			}
+			if err := os.Setenv(e.key, e.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type dotenvSource struct {
+	file   string
+	values map[string]string
+	lines  map[string]int
+}
+
+func (d *dotenvSource) Lookup(key string) (string, bool) {
+	v, ok := d.values[key]
+	return v, ok
+}
+
+func (d *dotenvSource) Keys() []string {
+	keys := make([]string, 0, len(d.values))
+	for k := range d.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DotenvSource returns a Lookuper backed by the named dotenv file without
+// touching the process environment.
+func DotenvSource(filename string) (Lookuper, error) {
+	entries, err := readDotenvFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return newDotenvSource(filename, entries), nil
+}
+
+func newDotenvSource(file string, entries []dotenvEntry) *dotenvSource {
+	d := &dotenvSource{
+		file:   file,
+		values: make(map[string]string, len(entries)),
+		lines:  make(map[string]int, len(entries)),
+	}
+	for _, e := range entries {
+		d.values[e.key] = e.value
+		d.lines[e.key] = e.line
+	}
+	return d
+}
+
+func readDotenvFile(filename string) ([]dotenvEntry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseDotenv(filename, f, lookupEnv)
+}
+
+func parseDotenv(file string, r io.Reader, fallback func(string) (string, bool)) ([]dotenvEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dotenvParser{
+		file:     file,
+		src:      string(data),
+		line:     1,
+		seen:     make(map[string]string),
+		fallback: fallback,
+	}
+	return p.parse()
+}
+
+type dotenvParser struct {
+	file     string
+	src      string
+	pos      int
+	line     int
+	seen     map[string]string
+	fallback func(string) (string, bool)
+}
+
+func (p *dotenvParser) errorf(line int, format string, args ...any) error {
+	return &DotenvError{File: p.file, Line: line, Err: fmt.Errorf(format, args...)}
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *dotenvParser) skipBlanks() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) parse() ([]dotenvEntry, error) {
+	var entries []dotenvEntry
+	for {
+		for !p.eof() && isDotenvSpace(p.peek()) {
+			p.next()
+		}
+		if p.eof() {
+			return entries, nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		line := p.line
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(line)
+		if err != nil {
+			return nil, err
+		}
+
+		p.seen[key] = value
+		entries = append(entries, dotenvEntry{key: key, value: value, line: line})
+	}
+}
+
+func (p *dotenvParser) parseKey() (string, error) {
+	line := p.line
+	if strings.HasPrefix(p.src[p.pos:], "export") {
+		rest := p.src[p.pos+len("export"):]
+		if rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			p.pos += len("export")
+			p.skipBlanks()
+		}
+	}
+
+	start := p.pos
+	for !p.eof() && isDotenvKeyChar(p.peek()) {
+		p.pos++
+	}
+	key := p.src[start:p.pos]
+	if key == "" {
+		return "", p.errorf(line, "invalid key")
+	}
+	if key[0] >= '0' && key[0] <= '9' {
+		return "", p.errorf(line, "invalid key %q", key)
+	}
+
+	p.skipBlanks()
+	if p.eof() || p.peek() != '=' {
+		return "", p.errorf(line, "expected '=' after key %q", key)
+	}
+	p.pos++
+	p.skipBlanks()
+	return key, nil
+}
+
+func (p *dotenvParser) parseValue(line int) (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	switch p.peek() {
+	case '"':
+		return p.parseDoubleQuoted(line)
+	case '\'':
+		return p.parseSingleQuoted(line)
+	}
+	return p.parseUnquoted(line)
+}
+
+func (p *dotenvParser) parseSingleQuoted(line int) (string, error) {
+	p.next()
+	end := strings.IndexByte(p.src[p.pos:], '\'')
+	if end < 0 {
+		return "", p.errorf(line, "unterminated single-quoted value")
+	}
+	value := p.src[p.pos : p.pos+end]
+	p.line += strings.Count(value, "\n")
+	p.pos += end + 1
+	return value, p.endOfValue(line)
+}
+
+func (p *dotenvParser) parseDoubleQuoted(line int) (string, error) {
+	p.next()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf(line, "unterminated double-quoted value")
+		}
+		c := p.next()
+		switch c {
+		case '"':
+			return b.String(), p.endOfValue(line)
+		case '\\':
+			if p.eof() {
+				return "", p.errorf(line, "unterminated double-quoted value")
+			}
+			switch e := p.next(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$', '\'':
+				b.WriteByte(e)
+			case '\n':
+				// Line continuation.
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		case '$':
+			if err := p.expand(&b, line); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *dotenvParser) parseUnquoted(line int) (string, error) {
+	var b strings.Builder
+	for !p.eof() && p.peek() != '\n' {
+		c := p.peek()
+		if c == '#' && (b.Len() == 0 || isDotenvBlank(b.String()[b.Len()-1])) {
+			p.skipLine()
+			break
+		}
+		p.pos++
+		if c == '$' {
+			if err := p.expand(&b, line); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return strings.TrimRight(b.String(), " \t\r"), nil
+}
+
+// endOfValue consumes the rest of the line after a quoted value, which may
+// only contain blanks and a comment.
+func (p *dotenvParser) endOfValue(line int) error {
+	p.skipBlanks()
+	if p.eof() {
+		return nil
+	}
+	switch p.peek() {
+	case '#':
+		p.skipLine()
+	case '\r', '\n':
+	default:
+		return p.errorf(line, "unexpected character %q after quoted value", p.peek())
+	}
+	return nil
+}
+
+// expand writes the value of the variable reference that follows a '$'.
+func (p *dotenvParser) expand(b *strings.Builder, line int) error {
+	if p.eof() {
+		b.WriteByte('$')
+		return nil
+	}
+
+	var name string
+	if p.peek() == '{' {
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 || strings.ContainsRune(p.src[p.pos:p.pos+end], '\n') {
+			return p.errorf(line, "unterminated variable reference")
+		}
+		name = p.src[p.pos+1 : p.pos+end]
+		if name == "" {
+			return p.errorf(line, "empty variable reference")
+		}
+		p.pos += end + 1
+	} else {
+		start := p.pos
+		for !p.eof() && isDotenvKeyChar(p.peek()) && p.peek() != '.' && p.peek() != '-' {
+			p.pos++
+		}
+		name = p.src[start:p.pos]
+		if name == "" {
+			b.WriteByte('$')
+			return nil
+		}
+	}
+
+	if v, ok := p.seen[name]; ok {
+		b.WriteString(v)
+	} else if p.fallback != nil {
+		v, _ := p.fallback(name)
+		b.WriteString(v)
+	}
+	return nil
+}
+
+func isDotenvKeyChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isDotenvSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isDotenvBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
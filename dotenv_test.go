@@ -0,0 +1,201 @@
+package envx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	t.Setenv("ENVX_DOTENV_HOME", "/home/envx")
+
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{
+			name:  "plain",
+			input: "FOO=bar\nBAZ=qux\n",
+			want:  map[string]string{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name:  "comments and blank lines",
+			input: "# header\n\nFOO=bar # trailing\n  # indented\nHASH=a#b\n",
+			want:  map[string]string{"FOO": "bar", "HASH": "a#b"},
+		},
+		{
+			name:  "export prefix",
+			input: "export FOO=bar\nexport\tBAZ = qux\nexporter=1\n",
+			want:  map[string]string{"FOO": "bar", "BAZ": "qux", "exporter": "1"},
+		},
+		{
+			name:  "empty values",
+			input: "A=\nB=''\nC=\"\"\n",
+			want:  map[string]string{"A": "", "B": "", "C": ""},
+		},
+		{
+			name:  "single quotes are literal",
+			input: `FOO='a\nb ${BAR} # not a comment'`,
+			want:  map[string]string{"FOO": `a\nb ${BAR} # not a comment`},
+		},
+		{
+			name:  "double quote escapes",
+			input: `FOO="tab\there \"quoted\" back\\slash \$HOME"`,
+			want:  map[string]string{"FOO": "tab\there \"quoted\" back\\slash $HOME"},
+		},
+		{
+			name:  "multiline double quoted",
+			input: "CERT=\"line1\nline2\"\nNEXT=ok\n",
+			want:  map[string]string{"CERT": "line1\nline2", "NEXT": "ok"},
+		},
+		{
+			name:  "multiline single quoted",
+			input: "KEY='-----BEGIN-----\nabc\n-----END-----' # pem\n",
+			want:  map[string]string{"KEY": "-----BEGIN-----\nabc\n-----END-----"},
+		},
+		{
+			name:  "expansion from earlier keys",
+			input: "USER=app\nHOST=db\nURL=postgres://${USER}@$HOST/x\nQUOTED=\"${USER}-x\"\n",
+			want: map[string]string{
+				"USER":   "app",
+				"HOST":   "db",
+				"URL":    "postgres://app@db/x",
+				"QUOTED": "app-x",
+			},
+		},
+		{
+			name:  "expansion from environment",
+			input: "CACHE=${ENVX_DOTENV_HOME}/.cache\nMISSING=[${ENVX_DOTENV_NOPE}]\nDOLLAR=5$\n",
+			want: map[string]string{
+				"CACHE":   "/home/envx/.cache",
+				"MISSING": "[]",
+				"DOLLAR":  "5$",
+			},
+		},
+		{
+			name:  "crlf line endings",
+			input: "FOO=bar\r\nBAZ=\"qux\"\r\n",
+			want:  map[string]string{"FOO": "bar", "BAZ": "qux"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseDotenv() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseDotenv() = %q, want %q", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("ParseDotenv()[%s] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{"missing equals", "FOO=bar\nBAZ\n", 2},
+		{"invalid key", "FOO=bar\n\n1ABC=x\n", 3},
+		{"unterminated double quote", "A=1\nB=\"abc\nC=2\n", 2},
+		{"unterminated single quote", "A='abc", 1},
+		{"garbage after quote", "A=\"abc\" def\n", 1},
+		{"unterminated reference", "A=${B\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDotenv(strings.NewReader(tt.input))
+			var derr *DotenvError
+			if !errors.As(err, &derr) {
+				t.Fatalf("ParseDotenv() expected *DotenvError, got %v", err)
+			}
+			if derr.Line != tt.line {
+				t.Errorf("DotenvError.Line = %d, want %d", derr.Line, tt.line)
+			}
+		})
+	}
+}
+
+func TestDotenvErrorMessage(t *testing.T) {
+	err := &DotenvError{File: ".env", Line: 3, Err: errors.New("invalid key")}
+	if got, want := err.Error(), "envx: .env:3: invalid key"; got != want {
+		t.Errorf("DotenvError.Error() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	content := "ENVX_LOAD_A=from_file\nENVX_LOAD_B=from_file\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVX_LOAD_A", "from_env")
+	t.Setenv("ENVX_LOAD_B", "")
+	os.Unsetenv("ENVX_LOAD_B")
+
+	if err := LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() unexpected error: %v", err)
+	}
+
+	if got := os.Getenv("ENVX_LOAD_A"); got != "from_env" {
+		t.Errorf("Expected existing variable to win, got %q", got)
+	}
+	if got := os.Getenv("ENVX_LOAD_B"); got != "from_file" {
+		t.Errorf("Expected ENVX_LOAD_B 'from_file', got %q", got)
+	}
+}
+
+func TestLoadFileErrorReportsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.env")
+	if err := os.WriteFile(path, []byte("OK=1\nBROKEN\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := LoadFile(path)
+	var derr *DotenvError
+	if !errors.As(err, &derr) {
+		t.Fatalf("LoadFile() expected *DotenvError, got %v", err)
+	}
+	if derr.File != path || derr.Line != 2 {
+		t.Errorf("DotenvError = %s:%d, want %s:2", derr.File, derr.Line, path)
+	}
+}
+
+func TestDotenvSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "APP_TEST_STRING=\"hello world\"\nAPP_TEST_INT=5\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := DotenvSource(path)
+	if err != nil {
+		t.Fatalf("DotenvSource() unexpected error: %v", err)
+	}
+
+	config := &TestConfig{}
+	if err := ProcessWith("APP", config, WithLookuper(src)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.StringField != "hello world" || config.IntField != 5 {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+
+	if err := CheckDisallowed("APP", config, WithLookuper(src)); err != nil {
+		t.Errorf("CheckDisallowed() unexpected error: %v", err)
+	}
+}
@@ -38,84 +38,52 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("envx.Process: assigning %[1]s to %[2]s: converting '%[3]s' to type %[4]s. details: %[5]s", e.KeyName, e.FieldName, e.Value, e.TypeName, e.Err)
 }
 
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 type varInfo struct {
-	Name  string
-	Alt   string
-	Key   string
-	Field reflect.Value
-	Tags  reflect.StructTag
+	Name    string
+	Path    string
+	Alt     string
+	Key     string
+	Aliases []string
+	Field   reflect.Value
+	Opts    fieldTags
+	Decode  decodeMethod
+	Unknown []unknownTag
 }
 
-func gatherInfo(prefix string, spec any) ([]varInfo, error) {
+func gatherInfo(prefix string, spec any, n keyNaming) ([]varInfo, error) {
 	s := reflect.ValueOf(spec)
 
 	if s.Kind() != reflect.Pointer || s.Elem().Kind() != reflect.Struct {
 		return nil, ErrInvalidSpecification
 	}
 	s = s.Elem()
-	typeOfSpec := s.Type()
-
-	infos := make([]varInfo, 0, s.NumField())
-	for i := 0; i < s.NumField(); i++ {
-		field := s.Field(i)
-		fieldType := typeOfSpec.Field(i)
-
-		if !field.CanSet() || isTrue(fieldType.Tag.Get("ignored")) {
-			continue
-		}
 
-		for field.Kind() == reflect.Pointer {
-			if field.IsNil() {
-				if field.Type().Elem().Kind() != reflect.Struct {
-					break
-				}
-				field.Set(reflect.New(field.Type().Elem()))
-			}
-			field = field.Elem()
-		}
-
-		info := varInfo{
-			Name:  fieldType.Name,
-			Field: field,
-			Tags:  fieldType.Tag,
-			Alt:   strings.ToUpper(fieldType.Tag.Get("envx")),
-		}
-
-		info.Key = info.Name
-
-		if isTrue(fieldType.Tag.Get("split_words")) {
-			info.Key = toSnakeCase(info.Name)
-		}
-
-		if info.Alt != "" {
-			info.Key = info.Alt
-		}
-
-		// For nested structs, always use "_" separator (not "__")
-		separator := "_"
+	plan, err := planFor(s.Type(), n)
+	if err != nil {
+		return nil, err
+	}
 
-		if prefix != "" {
-			info.Key = fmt.Sprintf("%s%s%s", prefix, separator, info.Key)
+	keyPrefix := n.keyPrefix(prefix)
+	infos := make([]varInfo, len(plan))
+	for i, p := range plan {
+		key := p.Key
+		if !p.Absolute {
+			key = keyPrefix + key
 		}
-		info.Key = strings.ToUpper(info.Key)
-		infos = append(infos, info)
-
-		if field.Kind() == reflect.Struct {
-			if decoderFrom(field) == nil && setterFrom(field) == nil &&
-				textUnmarshaler(field) == nil && binaryUnmarshaler(field) == nil {
-				innerPrefix := prefix
-				if !fieldType.Anonymous {
-					innerPrefix = info.Key
-				}
-
-				embeddedPtr := field.Addr().Interface()
-				embeddedInfos, err := gatherInfo(innerPrefix, embeddedPtr)
-				if err != nil {
-					return nil, err
-				}
-				infos = append(infos[:len(infos)-1], embeddedInfos...)
-				continue
-			}
+		infos[i] = varInfo{
+			Name:    p.Name,
+			Path:    p.Path,
+			Alt:     p.Alt,
+			Key:     key,
+			Aliases: p.Aliases,
+			Field:   p.field(s),
+			Opts:    p.Opts,
+			Decode:  p.Decode,
+			Unknown: p.Unknown,
 		}
 	}
 	return infos, nil
@@ -139,27 +107,55 @@ func toSnakeCase(s string) string {
 	return strings.Join(result, "_")
 }
 
-func CheckDisallowed(prefix string, spec any) error {
-	infos, err := gatherInfo(prefix, spec)
+func CheckDisallowed(prefix string, spec any, opts ...Option) error {
+	o := newOptions(opts)
+	lister, ok := o.lookuper.(KeyLister)
+	if !ok {
+		return ErrNotListable
+	}
+
+	infos, err := gatherInfo(prefix, spec, o.naming)
 	if err != nil {
 		return err
 	}
 
+	fold := func(s string) string {
+		if o.caseInsensitive {
+			return strings.ToUpper(s)
+		}
+		return s
+	}
+
 	vars := make(map[string]struct{})
 	for _, info := range infos {
-		vars[info.Key] = struct{}{}
+		vars[fold(info.Key)] = struct{}{}
+		for _, alias := range info.Aliases {
+			vars[fold(alias)] = struct{}{}
+		}
+		if o.secretFiles {
+			vars[fold(info.Key+"_FILE")] = struct{}{}
+			if info.Alt != "" {
+				vars[fold(info.Alt+"_FILE")] = struct{}{}
+			}
+		}
 	}
 
-	if prefix != "" {
-		prefix = strings.ToUpper(prefix) + "_"
-	}
+	prefix = fold(o.naming.keyPrefix(prefix))
 
-	for _, env := range os.Environ() {
-		if !strings.HasPrefix(env, prefix) {
+	seen := make(map[string]string)
+	for _, v := range lister.Keys() {
+		key := fold(v)
+		if !strings.HasPrefix(key, prefix) {
 			continue
 		}
-		v := strings.SplitN(env, "=", 2)[0]
-		if _, found := vars[v]; !found {
+		if prev, dup := seen[key]; dup && prev != v {
+			return fmt.Errorf("envx: %w: %s and %s", ErrAmbiguousKey, prev, v)
+		}
+		seen[key] = v
+		if v == o.jsonVar {
+			continue
+		}
+		if _, found := vars[key]; !found {
 			return fmt.Errorf("unknown environment variable %s", v)
 		}
 	}
@@ -168,32 +164,134 @@ func CheckDisallowed(prefix string, spec any) error {
 }
 
 func Process(prefix string, spec any) error {
-	infos, err := gatherInfo(prefix, spec)
+	return ProcessWith(prefix, spec)
+}
+
+// ProcessWith is like Process but reads values from the source configured
+// by opts.
+func ProcessWith(prefix string, spec any, opts ...Option) error {
+	o := newOptions(opts)
+
+	infos, err := gatherInfo(prefix, spec, o.naming)
 	if err != nil {
 		return err
 	}
+	if o.strict {
+		if err := checkUnknownTags(infos, o.allowedTags); err != nil {
+			return err
+		}
+	}
+
+	o.lookuper = snapshotSources(o.lookuper)
+
+	if o.jsonVar != "" {
+		src, err := newJSONVarSource(o.lookuper, o.jsonVar, prefix, o.naming)
+		if err != nil {
+			return err
+		}
+		if src != nil {
+			o.lookuper = MultiSource(o.lookuper, src)
+		}
+	}
+
+	if o.caseInsensitive {
+		f := newFoldSource(o.lookuper)
+		for _, info := range infos {
+			if err := f.checkAmbiguous(info.Key); err != nil {
+				return err
+			}
+			if err := f.checkAmbiguous(info.Alt); err != nil {
+				return err
+			}
+			for _, alias := range info.Aliases {
+				if err := f.checkAmbiguous(alias); err != nil {
+					return err
+				}
+			}
+		}
+		o.lookuper = f
+	}
+
+	var in *interpolator
+	if o.interpolate {
+		in = newInterpolator(o.lookuper)
+	}
+
+	var refs *refResolver
+	if o.references {
+		refs = newRefResolver(o)
+	}
+
+	var dec *decrypter
+	if o.decrypt {
+		dec = &decrypter{keyring: o.keyring}
+	}
+
+	var creds *credentialStore
+	if o.credentials {
+		creds, err = newCredentialStore()
+		if err != nil {
+			return err
+		}
+	}
 
 	for _, info := range infos {
-		value, ok := lookupEnv(info.Key)
+		var (
+			value  string
+			ok     bool
+			origin Origin
+		)
+		if creds != nil {
+			value, origin, ok = creds.lookup(info)
+		}
+		if !ok {
+			origin = Origin{Key: info.Key, Kind: FromKey}
+			value, ok = o.lookuper.Lookup(info.Key)
+		}
 		if !ok && info.Alt != "" {
-			value, ok = lookupEnv(info.Alt)
+			value, ok = o.lookuper.Lookup(info.Alt)
+			origin = Origin{Key: info.Alt, Kind: FromAlt}
 		}
-
 		if !ok {
-			value = tryNestedKeys(info.Key)
+			for _, alias := range info.Aliases {
+				if value, ok = o.lookuper.Lookup(alias); ok {
+					origin = Origin{Key: alias, Kind: FromAlias}
+					if o.aliasWarning != nil {
+						o.aliasWarning(alias, info.Key)
+					}
+					break
+				}
+			}
+		}
+
+		// A credential already holds the secret, never a path to it.
+		fromFile := info.Opts.File && origin.Source != "credentials"
+		if !ok && o.secretFiles {
+			value, origin, ok = lookupFileKey(o.lookuper, info)
+			fromFile = fromFile || ok
+		}
+
+		// Neither envconfig nor caarlos0/env fall back to truncated keys.
+		if !ok && !o.strict && o.naming.dialect == DialectEnvx {
+			var key string
+			key, value = tryNestedKeys(o.lookuper, info.Key, o.naming.separator())
 			if value != "" {
 				ok = true
+				origin = Origin{Key: key, Kind: FromNested}
 			}
 		}
 
-		def := info.Tags.Get("default")
+		def := info.Opts.Default
 		if def != "" && !ok {
 			value = def
+			origin = Origin{Kind: FromDefault, Source: "default tag"}
 		}
 
-		req := info.Tags.Get("required")
 		if !ok && def == "" {
-			if isTrue(req) {
+			if info.Opts.Required {
+				if name := info.Opts.Credential; creds != nil && name != "" {
+					return creds.missing(name)
+				}
 				key := info.Key
 				if info.Alt != "" {
 					key = info.Alt
@@ -203,7 +301,64 @@ func Process(prefix string, spec any) error {
 			continue
 		}
 
-		err = processField(value, info.Field)
+		if ok && value == "" && info.Opts.NotEmpty {
+			return fmt.Errorf("required key %s is empty", info.Key)
+		}
+
+		if info.Opts.Expand {
+			value = os.Expand(value, func(key string) string {
+				v, _ := o.lookuper.Lookup(key)
+				return v
+			})
+		}
+
+		if in != nil {
+			value, err = in.value(origin.Key, value)
+			if err != nil {
+				return fmt.Errorf("envx.Process: interpolating %s: %w", info.Key, err)
+			}
+		}
+
+		if dec != nil {
+			value, err = dec.decrypt(value)
+			if err != nil {
+				return &DecryptError{
+					KeyName:   info.Key,
+					FieldName: info.Name,
+					Err:       err,
+				}
+			}
+		}
+
+		if refs != nil {
+			ref := value
+			value, err = refs.resolve(ref)
+			if err != nil {
+				return &ParseError{
+					KeyName:   info.Key,
+					FieldName: info.Name,
+					TypeName:  info.Field.Type().String(),
+					Value:     ref,
+					Err:       err,
+				}
+			}
+		}
+
+		if fromFile {
+			path := value
+			value, err = readSecretFile(path, o.secretFileMode)
+			if err != nil {
+				return &SecretFileError{
+					KeyName:   info.Key,
+					FieldName: info.Name,
+					Path:      path,
+					Err:       err,
+				}
+			}
+			origin.SecretFile = path
+		}
+
+		err = info.Decode.decode(value, info.Field, info.Opts.Sep, info.Opts.KVSep)
 		if err != nil {
 			return &ParseError{
 				KeyName:   info.Key,
@@ -213,11 +368,34 @@ func Process(prefix string, spec any) error {
 				Err:       err,
 			}
 		}
+
+		if info.Opts.Unset && origin.Key != "" {
+			if err := os.Unsetenv(origin.Key); err != nil {
+				return err
+			}
+		}
+
+		if o.provenance != nil {
+			if origin.Source == "" {
+				origin = locate(o.lookuper, origin)
+			}
+			(*o.provenance)[info.Path] = origin
+		}
 	}
 
 	return nil
 }
 
+// ProcessMap populates spec from m instead of the process environment.
+func ProcessMap(prefix string, spec any, m map[string]string) error {
+	return ProcessWith(prefix, spec, WithLookuper(MapSource(m)))
+}
+
+// ProcessEnviron populates spec from a "KEY=value" slice.
+func ProcessEnviron(prefix string, spec any, environ []string) error {
+	return ProcessWith(prefix, spec, WithLookuper(EnvironSource(environ)))
+}
+
 func MustProcess(prefix string, spec any) {
 	if err := Process(prefix, spec); err != nil {
 		panic(err)
@@ -225,25 +403,76 @@ func MustProcess(prefix string, spec any) {
 }
 
 func processField(value string, field reflect.Value) error {
-	typ := field.Type()
+	return processFieldSep(value, field, ",", ":")
+}
 
-	decoder := decoderFrom(field)
-	if decoder != nil {
-		return decoder.Decode(value)
-	}
+// processFieldSep is processField with sep separating slice elements and
+// map pairs, and kvSep separating the key and value of a pair.
+func processFieldSep(value string, field reflect.Value, sep, kvSep string) error {
+	return decodeMethodOf(field.Type()).decode(value, field, sep, kvSep)
+}
 
-	setter := setterFrom(field)
-	if setter != nil {
-		return setter.Set(value)
+// decodeMethod is how a field of a given type is converted from a string.
+type decodeMethod uint8
+
+const (
+	decodeKind decodeMethod = iota
+	decodeDecoder
+	decodeSetter
+	decodeText
+	decodeBinary
+)
+
+var (
+	decoderType           = reflect.TypeFor[Decoder]()
+	setterType            = reflect.TypeFor[Setter]()
+	textUnmarshalerType   = reflect.TypeFor[encoding.TextUnmarshaler]()
+	binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+)
+
+// decodeMethodOf returns the decodeMethod of an addressable value of type t.
+func decodeMethodOf(t reflect.Type) decodeMethod {
+	implements := func(iface reflect.Type) bool {
+		return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+	}
+	switch {
+	case implements(decoderType):
+		return decodeDecoder
+	case implements(setterType):
+		return decodeSetter
+	case implements(textUnmarshalerType):
+		return decodeText
+	case implements(binaryUnmarshalerType):
+		return decodeBinary
 	}
+	return decodeKind
+}
 
-	if t := textUnmarshaler(field); t != nil {
-		return t.UnmarshalText([]byte(value))
+func (m decodeMethod) decode(value string, field reflect.Value, sep, kvSep string) error {
+	switch m {
+	case decodeDecoder:
+		return methodValue(field, decoderType).(Decoder).Decode(value)
+	case decodeSetter:
+		return methodValue(field, setterType).(Setter).Set(value)
+	case decodeText:
+		return methodValue(field, textUnmarshalerType).(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	case decodeBinary:
+		return methodValue(field, binaryUnmarshalerType).(encoding.BinaryUnmarshaler).UnmarshalBinary([]byte(value))
 	}
+	return decodeByKind(value, field, sep, kvSep)
+}
 
-	if b := binaryUnmarshaler(field); b != nil {
-		return b.UnmarshalBinary([]byte(value))
+// methodValue returns field, or its address when only the pointer
+// implements iface.
+func methodValue(field reflect.Value, iface reflect.Type) any {
+	if field.Type().Implements(iface) {
+		return field.Interface()
 	}
+	return field.Addr().Interface()
+}
+
+func decodeByKind(value string, field reflect.Value, sep, kvSep string) error {
+	typ := field.Type()
 
 	if typ.Kind() == reflect.Pointer {
 		// Handle special case for *time.Location
@@ -320,7 +549,7 @@ func processField(value string, field reflect.Value) error {
 		if typ.Elem().Kind() == reflect.Uint8 {
 			sl = reflect.ValueOf([]byte(value))
 		} else if strings.TrimSpace(value) != "" {
-			vals := strings.Split(value, ",")
+			vals := strings.Split(value, sep)
 			sl = reflect.MakeSlice(typ, len(vals), len(vals))
 			for i, val := range vals {
 				err := processField(val, sl.Index(i))
@@ -333,9 +562,9 @@ func processField(value string, field reflect.Value) error {
 	case reflect.Map:
 		mp := reflect.MakeMap(typ)
 		if strings.TrimSpace(value) != "" {
-			pairs := strings.SplitSeq(value, ",")
+			pairs := strings.SplitSeq(value, sep)
 			for pair := range pairs {
-				kvpair := strings.Split(pair, ":")
+				kvpair := strings.Split(pair, kvSep)
 				if len(kvpair) != 2 {
 					return fmt.Errorf("invalid map item: %q", pair)
 				}
@@ -358,37 +587,6 @@ func processField(value string, field reflect.Value) error {
 	return nil
 }
 
-func interfaceFrom(field reflect.Value, fn func(any, *bool)) {
-	if !field.CanInterface() {
-		return
-	}
-	var ok bool
-	fn(field.Interface(), &ok)
-	if !ok && field.CanAddr() {
-		fn(field.Addr().Interface(), &ok)
-	}
-}
-
-func decoderFrom(field reflect.Value) (d Decoder) {
-	interfaceFrom(field, func(v any, ok *bool) { d, *ok = v.(Decoder) })
-	return d
-}
-
-func setterFrom(field reflect.Value) (s Setter) {
-	interfaceFrom(field, func(v any, ok *bool) { s, *ok = v.(Setter) })
-	return s
-}
-
-func textUnmarshaler(field reflect.Value) (t encoding.TextUnmarshaler) {
-	interfaceFrom(field, func(v any, ok *bool) { t, *ok = v.(encoding.TextUnmarshaler) })
-	return t
-}
-
-func binaryUnmarshaler(field reflect.Value) (b encoding.BinaryUnmarshaler) {
-	interfaceFrom(field, func(v any, ok *bool) { b, *ok = v.(encoding.BinaryUnmarshaler) })
-	return b
-}
-
 func isTrue(s string) bool {
 	b, _ := strconv.ParseBool(s)
 	return b
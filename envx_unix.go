@@ -3,26 +3,21 @@
 package envx
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
-	"strings"
 )
 
+// envCaseInsensitive reports whether environment keys ignore case.
+const envCaseInsensitive = false
+
 func lookupEnv(key string) (string, bool) {
 	return os.LookupEnv(key)
 }
 
-func tryNestedKeys(key string) string {
-	if !strings.Contains(key, "_") {
-		return ""
-	}
-
-	parts := strings.Split(key, "_")
-	for i := len(parts); i > 1; i-- {
-		testKey := strings.Join(parts[:i], "_")
-		if value, ok := lookupEnv(testKey); ok {
-			return value
-		}
+func checkFileMode(mode fs.FileMode, maxPerm fs.FileMode) error {
+	if extra := mode.Perm() &^ maxPerm; extra != 0 {
+		return fmt.Errorf("%w: mode %04o exceeds %04o", ErrSecretFilePermissions, mode.Perm(), maxPerm)
 	}
-
-	return ""
+	return nil
 }
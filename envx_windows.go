@@ -3,10 +3,14 @@
 package envx
 
 import (
+	"io/fs"
 	"os"
 	"strings"
 )
 
+// envCaseInsensitive reports whether environment keys ignore case.
+const envCaseInsensitive = true
+
 func lookupEnv(key string) (string, bool) {
 	for _, env := range os.Environ() {
 		kv := strings.SplitN(env, "=", 2)
@@ -17,28 +21,8 @@ func lookupEnv(key string) (string, bool) {
 	return "", false
 }
 
-func tryNestedKeys(key string) string {
-	if !strings.Contains(key, "_") {
-		return ""
-	}
-
-	parts := strings.Split(key, "_")
-	for i := len(parts); i > 1; i-- {
-		testKey := strings.Join(parts[:i], "_")
-		if value := tryWindowsLookup(testKey); value != "" {
-			return value
-		}
-	}
-
-	return ""
-}
-
-func tryWindowsLookup(key string) string {
-	for _, env := range os.Environ() {
-		kv := strings.SplitN(env, "=", 2)
-		if len(kv) == 2 && strings.EqualFold(kv[0], key) {
-			return kv[1]
-		}
-	}
-	return ""
+// checkFileMode is a no-op on Windows, where Unix permission bits do not
+// describe who can read a file.
+func checkFileMode(mode fs.FileMode, maxPerm fs.FileMode) error {
+	return nil
 }
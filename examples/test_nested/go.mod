@@ -2,9 +2,11 @@ module test_example
 
 go 1.25.0
 
+require github.com/justblue0312/envx v0.0.0-00010101000000-000000000000
+
 require (
-	github.com/joho/godotenv v1.5.1
-	github.com/justblue0312/envx v0.0.0-00010101000000-000000000000
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/justblue0312/envx => ../../
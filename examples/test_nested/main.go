@@ -5,7 +5,6 @@ import (
 	"log"
 	"os"
 
-	"github.com/joho/godotenv"
 	"github.com/justblue0312/envx"
 )
 
@@ -21,7 +20,7 @@ type Config struct {
 }
 
 func main() {
-	err := godotenv.Load()
+	err := envx.LoadFile()
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
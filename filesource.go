@@ -0,0 +1,265 @@
+package envx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+)
+
+type fileSource struct {
+	file   string
+	values map[string]string
+	naming keyNaming
+}
+
+// FileSource returns a Lookuper backed by a JSON, TOML or YAML document.
+// The format is chosen by the file extension. Nested keys are flattened
+// into envx keys, so database.host becomes DATABASE_HOST. Options select
+// the key naming, so with DoubleUnderscore() it answers DATABASE__HOST.
+func FileSource(path string, opts ...Option) (Lookuper, error) {
+	format, err := formatFromExt(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values, err := ParseConfig(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("envx: %s: %w", path, err)
+	}
+	return &fileSource{file: path, values: values, naming: newOptions(opts).naming}, nil
+}
+
+// ParseConfig decodes a structured document in the given format and
+// flattens it into envx keys. Arrays of scalars are joined with "," and
+// objects holding only scalars are also exposed as "k:v" pairs, matching
+// what Process expects for slice and map fields.
+func ParseConfig(r io.Reader, format string) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&doc)
+	case FormatTOML:
+		var m map[string]any
+		err = toml.Unmarshal(data, &m)
+		doc = m
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if doc == nil {
+		return values, nil
+	}
+	if _, ok := asObject(doc); !ok {
+		return nil, fmt.Errorf("%s document must be an object", format)
+	}
+	flattenConfig("", doc, values)
+	return values, nil
+}
+
+func formatFromExt(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".toml":
+		return FormatTOML, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("envx: cannot detect config format of %s", path)
+}
+
+func flattenConfig(key string, v any, out map[string]string) {
+	if obj, ok := asObject(v); ok {
+		pairs := make([]string, 0, len(obj))
+		for k, child := range obj {
+			childKey := FileNameToKey(k)
+			if key != "" {
+				childKey = key + "_" + childKey
+			}
+			flattenConfig(childKey, child, out)
+			if s, ok := scalarString(child); ok && pairs != nil {
+				pairs = append(pairs, k+":"+s)
+			} else {
+				pairs = nil
+			}
+		}
+		if key != "" && pairs != nil {
+			sort.Strings(pairs)
+			out[key] = strings.Join(pairs, ",")
+		}
+		return
+	}
+
+	if tables, ok := v.([]map[string]any); ok {
+		arr := make([]any, len(tables))
+		for i, t := range tables {
+			arr[i] = t
+		}
+		v = arr
+	}
+
+	if arr, ok := v.([]any); ok {
+		items := make([]string, 0, len(arr))
+		for i, child := range arr {
+			flattenConfig(key+"_"+strconv.Itoa(i), child, out)
+			if s, ok := scalarString(child); ok && items != nil {
+				items = append(items, s)
+			} else {
+				items = nil
+			}
+		}
+		if items != nil {
+			out[key] = strings.Join(items, ",")
+		}
+		return
+	}
+
+	if s, ok := scalarString(v); ok {
+		out[key] = s
+	}
+}
+
+func asObject(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		obj := make(map[string]any, len(m))
+		for k, child := range m {
+			obj[fmt.Sprint(k)] = child
+		}
+		return obj, true
+	}
+	return nil, false
+}
+
+func scalarString(v any) (string, bool) {
+	switch s := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return s, true
+	case bool:
+		return strconv.FormatBool(s), true
+	case json.Number:
+		return s.String(), true
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64), true
+	case int, int64, uint64:
+		return fmt.Sprint(s), true
+	case time.Time:
+		return s.Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+// Lookup also answers keys in the document's own naming, such as
+// database.host, for specs processed with DottedKeys.
+func (f *fileSource) Lookup(key string) (string, bool) {
+	if v, ok := f.values[key]; ok {
+		return v, true
+	}
+	v, ok := f.values[f.naming.flatKey(key)]
+	return v, ok
+}
+
+func (f *fileSource) Keys() []string {
+	keys := make([]string, 0, len(f.values))
+	for k := range f.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (*fileSource) exactOnly() {}
+
+func (f *fileSource) locate(o Origin) Origin {
+	o.Source = f.file
+	o.File = f.file
+	return o
+}
+
+type prefixSource struct {
+	prefix string
+	naming keyNaming
+	Lookuper
+}
+
+// PrefixSource exposes the keys of l under prefix, so a source holding
+// DATABASE_HOST answers lookups for APP_DATABASE_HOST. Options select the
+// key naming, so with DoubleUnderscore() it answers APP__DATABASE__HOST
+// from DATABASE_HOST as flattened by FileSource.
+func PrefixSource(prefix string, l Lookuper, opts ...Option) Lookuper {
+	n := newOptions(opts).naming
+	return prefixSource{prefix: n.keyPrefix(prefix), naming: n, Lookuper: l}
+}
+
+func (p prefixSource) Lookup(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, p.prefix)
+	if !ok {
+		return "", false
+	}
+	if v, ok := p.Lookuper.Lookup(rest); ok {
+		return v, true
+	}
+	if flat := p.naming.flatKey(rest); flat != rest {
+		return p.Lookuper.Lookup(flat)
+	}
+	return "", false
+}
+
+func (p prefixSource) Keys() []string {
+	lister, ok := p.Lookuper.(KeyLister)
+	if !ok {
+		return nil
+	}
+	keys := lister.Keys()
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = p.prefix + k
+	}
+	return prefixed
+}
+
+func (p prefixSource) locate(o Origin) Origin {
+	inner := o
+	inner.Key = strings.TrimPrefix(o.Key, p.prefix)
+	inner = locate(p.Lookuper, inner)
+	inner.Key = o.Key
+	return inner
+}
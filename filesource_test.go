@@ -0,0 +1,234 @@
+package envx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fileConfig struct {
+	Name     string `envx:"NAME"`
+	Database struct {
+		Host    string        `envx:"HOST"`
+		Port    int           `envx:"PORT"`
+		Timeout time.Duration `envx:"TIMEOUT"`
+	} `envx:"DATABASE"`
+	Tags   []string          `envx:"TAGS"`
+	Ports  []int             `envx:"PORTS"`
+	Labels map[string]string `envx:"LABELS"`
+	Debug  bool              `envx:"DEBUG"`
+	Ratio  float64           `envx:"RATIO"`
+}
+
+func TestParseConfigFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{
+			format: FormatJSON,
+			input: `{
+				"name": "svc",
+				"database": {"host": "db", "port": 5432, "timeout": "5s"},
+				"tags": ["a", "b"],
+				"ports": [80, 443],
+				"labels": {"team": "core", "tier": "1"},
+				"debug": true,
+				"ratio": 0.25
+			}`,
+		},
+		{
+			format: FormatTOML,
+			input: `
+name = "svc"
+tags = ["a", "b"]
+ports = [80, 443]
+debug = true
+ratio = 0.25
+
+[database]
+host = "db"
+port = 5432
+timeout = "5s"
+
+[labels]
+team = "core"
+tier = "1"
+`,
+		},
+		{
+			format: FormatYAML,
+			input: `
+name: svc
+database:
+  host: db
+  port: 5432
+  timeout: 5s
+tags: [a, b]
+ports:
+  - 80
+  - 443
+labels:
+  team: core
+  tier: "1"
+debug: true
+ratio: 0.25
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			values, err := ParseConfig(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("ParseConfig() unexpected error: %v", err)
+			}
+
+			config := &fileConfig{}
+			if err := ProcessMap("", config, values); err != nil {
+				t.Fatalf("ProcessMap() unexpected error: %v", err)
+			}
+
+			if config.Name != "svc" || config.Database.Host != "db" || config.Database.Port != 5432 {
+				t.Errorf("ProcessMap() = %+v", config)
+			}
+			if config.Database.Timeout != 5*time.Second {
+				t.Errorf("Expected Timeout 5s, got %v", config.Database.Timeout)
+			}
+			if len(config.Tags) != 2 || config.Tags[1] != "b" {
+				t.Errorf("Expected Tags [a b], got %v", config.Tags)
+			}
+			if len(config.Ports) != 2 || config.Ports[1] != 443 {
+				t.Errorf("Expected Ports [80 443], got %v", config.Ports)
+			}
+			if config.Labels["team"] != "core" || config.Labels["tier"] != "1" {
+				t.Errorf("Expected Labels, got %v", config.Labels)
+			}
+			if !config.Debug || config.Ratio != 0.25 {
+				t.Errorf("Expected Debug true and Ratio 0.25, got %v %v", config.Debug, config.Ratio)
+			}
+		})
+	}
+}
+
+func TestParseConfigArrayOfObjects(t *testing.T) {
+	values, err := ParseConfig(strings.NewReader(`{"servers": [{"host": "a"}, {"host": "b"}]}`), FormatJSON)
+	if err != nil {
+		t.Fatalf("ParseConfig() unexpected error: %v", err)
+	}
+	if values["SERVERS_0_HOST"] != "a" || values["SERVERS_1_HOST"] != "b" {
+		t.Errorf("ParseConfig() = %v", values)
+	}
+	if _, ok := values["SERVERS"]; ok {
+		t.Errorf("Arrays of objects should not be joined")
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{"bad json", FormatJSON, `{"a":`},
+		{"top level array", FormatJSON, `[1, 2]`},
+		{"bad yaml", FormatYAML, "a: [1, 2"},
+		{"bad toml", FormatTOML, "a = "},
+		{"unknown format", "ini", "a=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseConfig(strings.NewReader(tt.input), tt.format); err == nil {
+				t.Errorf("ParseConfig() expected error")
+			}
+		})
+	}
+}
+
+func TestFileSourceOverriddenByEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": "name: from-file\ndatabase:\n  host: file-host\n  port: 1\n",
+	})
+
+	file, err := FileSource(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("FileSource() unexpected error: %v", err)
+	}
+
+	var prov Provenance
+	config := &fileConfig{}
+	err = NewLoader("APP").
+		WithSource("env", MapSource(map[string]string{"APP_DATABASE_PORT": "6543"})).
+		WithSource("config.yaml", PrefixSource("APP", file)).
+		WithOptions(WithProvenance(&prov)).
+		Load(config)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if config.Name != "from-file" || config.Database.Host != "file-host" || config.Database.Port != 6543 {
+		t.Errorf("Load() = %+v", config)
+	}
+	if got := prov["Database.Host"]; got.Source != "config.yaml" || got.Key != "APP_DATABASE_HOST" {
+		t.Errorf("Provenance[Database.Host] = %+v", got)
+	}
+}
+
+func TestPrefixSourceSeparator(t *testing.T) {
+	src := MapSource(map[string]string{"DATABASE_HOST": "x"})
+
+	if v, ok := PrefixSource("APP", src, DoubleUnderscore()).Lookup("APP__DATABASE_HOST"); !ok || v != "x" {
+		t.Errorf("Lookup(APP__DATABASE_HOST) = %q, %v", v, ok)
+	}
+	if _, ok := PrefixSource("APP", src, DoubleUnderscore()).Lookup("APP_DATABASE_HOST"); ok {
+		t.Errorf("Lookup(APP_DATABASE_HOST) expected miss with DoubleUnderscore")
+	}
+	if v, ok := PrefixSource("app", src, DottedKeys()).Lookup("app.DATABASE_HOST"); !ok || v != "x" {
+		t.Errorf("Lookup(app.DATABASE_HOST) = %q, %v", v, ok)
+	}
+}
+
+func TestFileSourceSeparator(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": "name: svc\ndatabase:\n  host: file-host\n  port: 5432\n",
+	})
+	path := filepath.Join(dir, "config.yaml")
+
+	direct, err := FileSource(path, DoubleUnderscore())
+	if err != nil {
+		t.Fatalf("FileSource() unexpected error: %v", err)
+	}
+	plain, err := FileSource(path)
+	if err != nil {
+		t.Fatalf("FileSource() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		src    Lookuper
+	}{
+		{name: "file source", src: direct},
+		{name: "prefix source", prefix: "APP", src: PrefixSource("APP", plain, DoubleUnderscore())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &fileConfig{}
+			if err := ProcessWith(tt.prefix, config, WithLookuper(tt.src), DoubleUnderscore()); err != nil {
+				t.Fatalf("ProcessWith() unexpected error: %v", err)
+			}
+			if config.Name != "svc" || config.Database.Host != "file-host" || config.Database.Port != 5432 {
+				t.Errorf("ProcessWith() = %+v", config)
+			}
+		})
+	}
+}
+
+func TestFileSourceUnknownExtension(t *testing.T) {
+	if _, err := FileSource("config.ini"); err == nil {
+		t.Errorf("FileSource() expected error for unknown extension")
+	}
+}
@@ -0,0 +1,146 @@
+package envx
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type flagValue struct {
+	key    string
+	name   string
+	typ    reflect.Type
+	def    string
+	sep    string
+	kvSep  string
+	value  string
+	set    bool
+	isBool bool
+	multi  bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	if f.set {
+		return f.value
+	}
+	return f.def
+}
+
+// Set validates value by converting it exactly as Process would.
+func (f *flagValue) Set(value string) error {
+	if err := processFieldSep(value, reflect.New(f.typ).Elem(), f.sep, f.kvSep); err != nil {
+		return err
+	}
+	if f.multi && f.set {
+		f.value += f.sep + value
+	} else {
+		f.value = value
+	}
+	f.set = true
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+type flagSource struct {
+	values map[string]*flagValue
+}
+
+func (s *flagSource) Lookup(key string) (string, bool) {
+	f, ok := s.values[key]
+	if !ok || !f.set {
+		return "", false
+	}
+	return f.value, true
+}
+
+func (s *flagSource) Keys() []string {
+	var keys []string
+	for k, f := range s.values {
+		if f.set {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *flagSource) locate(o Origin) Origin {
+	o.Source = "flag -" + s.values[o.Key].name
+	return o
+}
+
+func (*flagSource) exactOnly() {}
+
+// BindFlags registers one flag per field of spec on fs. Flag names are
+// derived from the field key without the prefix, so APP_DATABASE_HOST
+// becomes -database-host. The `default` tag is shown as the flag default
+// and the `desc` tag as its usage.
+//
+// Options select the key naming, as for ProcessWith; pass the same ones to
+// both so the flags answer the keys Process looks up.
+//
+// The returned Lookuper holds the flags that were set on the command line.
+// Put it in front of the other sources so flags take precedence:
+//
+//	flags, err := envx.BindFlags(fs, "APP", &cfg)
+//	fs.Parse(os.Args[1:])
+//	err = envx.NewLoader("APP").WithSource("flags", flags).WithEnv().Load(&cfg)
+func BindFlags(fs *flag.FlagSet, prefix string, spec any, opts ...Option) (Lookuper, error) {
+	n := newOptions(opts).naming
+	infos, err := gatherInfo(prefix, spec, n)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &flagSource{values: make(map[string]*flagValue, len(infos))}
+	for _, info := range infos {
+		name := flagName(n.keyPrefix(prefix), info.Key, n.separator())
+		if fs.Lookup(name) != nil {
+			return nil, fmt.Errorf("envx: flag -%s for %s already defined", name, info.Path)
+		}
+
+		typ := info.Field.Type()
+		f := &flagValue{
+			key:    info.Key,
+			name:   name,
+			typ:    typ,
+			def:    info.Opts.Default,
+			sep:    info.Opts.Sep,
+			kvSep:  info.Opts.KVSep,
+			isBool: typ.Kind() == reflect.Bool,
+			multi:  isMultiFlag(info.Field),
+		}
+		fs.Var(f, name, info.Opts.Desc)
+		src.values[info.Key] = f
+	}
+	return src, nil
+}
+
+func flagName(keyPrefix, key, sep string) string {
+	key = strings.TrimPrefix(key, keyPrefix)
+	key = strings.ReplaceAll(key, sep, "-")
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// isMultiFlag reports whether repeating the flag should accumulate values
+// rather than replace them.
+func isMultiFlag(field reflect.Value) bool {
+	if decodeMethodOf(field.Type()) != decodeKind {
+		return false
+	}
+	switch field.Kind() {
+	case reflect.Slice:
+		return field.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Map:
+		return true
+	}
+	return false
+}
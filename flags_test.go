@@ -0,0 +1,169 @@
+package envx
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagConfig struct {
+	Name     string `envx:"NAME" default:"svc" desc:"service name"`
+	Debug    bool   `envx:"DEBUG"`
+	Database struct {
+		Host string `envx:"HOST" default:"localhost" desc:"database host"`
+		Port int    `envx:"PORT"`
+	} `envx:"DATABASE"`
+	Timeout time.Duration     `envx:"TIMEOUT"`
+	Tags    []string          `envx:"TAGS"`
+	Labels  map[string]string `envx:"LABELS"`
+	Custom  CustomType        `envx:"CUSTOM"`
+}
+
+func TestBindFlags(t *testing.T) {
+	config := &flagConfig{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags, err := BindFlags(fs, "APP", config)
+	if err != nil {
+		t.Fatalf("BindFlags() unexpected error: %v", err)
+	}
+
+	err = fs.Parse([]string{
+		"-database-host", "flag-host",
+		"-debug",
+		"-timeout", "3s",
+		"-tags", "a", "-tags", "b,c",
+		"-labels", "k:v",
+		"-custom", "x",
+	})
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	err = NewLoader("APP").
+		WithSource("flags", flags).
+		WithSource("env", MapSource(map[string]string{
+			"APP_DATABASE_HOST": "env-host",
+			"APP_DATABASE_PORT": "5432",
+		})).
+		Load(config)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if config.Database.Host != "flag-host" {
+		t.Errorf("Expected flag to win, got %q", config.Database.Host)
+	}
+	if config.Database.Port != 5432 {
+		t.Errorf("Expected Port from env, got %d", config.Database.Port)
+	}
+	if config.Name != "svc" {
+		t.Errorf("Expected default Name, got %q", config.Name)
+	}
+	if !config.Debug || config.Timeout != 3*time.Second {
+		t.Errorf("Expected Debug and Timeout from flags, got %+v", config)
+	}
+	if strings.Join(config.Tags, "|") != "a|b|c" {
+		t.Errorf("Expected repeated -tags to accumulate, got %v", config.Tags)
+	}
+	if config.Labels["k"] != "v" {
+		t.Errorf("Expected Labels from flags, got %v", config.Labels)
+	}
+	if config.Custom.Value != "decoded:x" {
+		t.Errorf("Expected Decoder to run for flag value, got %q", config.Custom.Value)
+	}
+}
+
+func TestBindFlagsUsage(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := BindFlags(fs, "", &flagConfig{}); err != nil {
+		t.Fatalf("BindFlags() unexpected error: %v", err)
+	}
+
+	f := fs.Lookup("database-host")
+	if f == nil {
+		t.Fatalf("Expected -database-host to be registered")
+	}
+	if f.DefValue != "localhost" || f.Usage != "database host" {
+		t.Errorf("Flag = %+v", f)
+	}
+
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	if !strings.Contains(buf.String(), "service name") {
+		t.Errorf("PrintDefaults() = %q", buf.String())
+	}
+}
+
+func TestBindFlagsInvalidValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	if _, err := BindFlags(fs, "", &flagConfig{}); err != nil {
+		t.Fatalf("BindFlags() unexpected error: %v", err)
+	}
+	if err := fs.Parse([]string{"-database-port", "abc"}); err == nil {
+		t.Errorf("Parse() expected error for invalid int")
+	}
+}
+
+func TestBindFlagsDuplicate(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("name", "", "")
+	if _, err := BindFlags(fs, "", &flagConfig{}); err == nil {
+		t.Errorf("BindFlags() expected error for duplicate flag")
+	}
+}
+
+func TestBindFlagsUnsetFlagsFallThrough(t *testing.T) {
+	config := &flagConfig{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags, err := BindFlags(fs, "", config)
+	if err != nil {
+		t.Fatalf("BindFlags() unexpected error: %v", err)
+	}
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := flags.(KeyLister).Keys(); len(keys) != 0 {
+		t.Errorf("Expected no keys before flags are set, got %v", keys)
+	}
+	err = ProcessWith("", config, WithLookuper(MultiSource(flags, MapSource(map[string]string{"NAME": "env"}))))
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Name != "env" {
+		t.Errorf("Expected env value when flag is unset, got %q", config.Name)
+	}
+}
+
+func TestBindFlagsNaming(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host     string `envx:"HOST"`
+			MaxConns int    `envx:"MAX_CONNS"`
+		} `envx:"DB"`
+	}
+
+	config := &Config{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags, err := BindFlags(fs, "APP", config, DoubleUnderscore())
+	if err != nil {
+		t.Fatalf("BindFlags() unexpected error: %v", err)
+	}
+	if err := fs.Parse([]string{"-db-host", "flag-host", "-db-max-conns", "4"}); err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	if v, ok := flags.Lookup("APP__DB__HOST"); !ok || v != "flag-host" {
+		t.Errorf("Lookup(APP__DB__HOST) = %q, %v", v, ok)
+	}
+	if err := ProcessWith("APP", config, WithLookuper(flags), DoubleUnderscore()); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.DB.Host != "flag-host" || config.DB.MaxConns != 4 {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+}
@@ -0,0 +1,62 @@
+package envx
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// EmbeddedSourceName is reported by Provenance for values read from an
+// FSSource.
+const EmbeddedSourceName = "embedded default"
+
+type fsSource struct {
+	file string
+	Lookuper
+}
+
+// FSSource returns a Lookuper backed by the file at name in fsys, usually an
+// embed.FS holding defaults shipped with the binary. Files ending in .json,
+// .toml, .yaml or .yml are parsed with ParseConfig; anything else is read as
+// dotenv. Put it last so every other source takes precedence:
+//
+//	//go:embed defaults.env
+//	var defaults embed.FS
+//
+//	src, err := envx.FSSource(defaults, "defaults.env")
+//	err = envx.ProcessWith("APP", &cfg, envx.WithLookuper(envx.MultiSource(envx.OSSource(), src)))
+func FSSource(fsys fs.FS, name string) (Lookuper, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format, err := formatFromExt(name); err == nil {
+		values, err := ParseConfig(f, format)
+		if err != nil {
+			return nil, fmt.Errorf("envx: %s: %w", name, err)
+		}
+		return fsSource{file: name, Lookuper: &fileSource{file: name, values: values}}, nil
+	}
+
+	// Embedded defaults must not change with the environment, so references
+	// are only expanded from earlier lines of the file.
+	entries, err := parseDotenv(name, f, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fsSource{file: name, Lookuper: newDotenvSource(name, entries)}, nil
+}
+
+func (s fsSource) Keys() []string {
+	if lister, ok := s.Lookuper.(KeyLister); ok {
+		return lister.Keys()
+	}
+	return nil
+}
+
+func (s fsSource) locate(o Origin) Origin {
+	o = locate(s.Lookuper, o)
+	o.Source = EmbeddedSourceName
+	return o
+}
@@ -0,0 +1,72 @@
+package envx
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.env":  {Data: []byte("# shipped defaults\nAPP_NAME=svc\nAPP_DATABASE_HOST=db.internal\nAPP_DATABASE_PORT=5432\nAPP_URL=http://${APP_DATABASE_HOST}\n")},
+		"defaults.json": {Data: []byte(`{"app": {"name": "svc", "database": {"host": "db.internal", "port": 5432}}}`)},
+	}
+
+	type Config struct {
+		Name     string `envx:"NAME"`
+		Database struct {
+			Host string `envx:"HOST"`
+			Port int    `envx:"PORT"`
+		} `envx:"DATABASE"`
+	}
+
+	for _, name := range []string{"defaults.env", "defaults.json"} {
+		t.Run(name, func(t *testing.T) {
+			src, err := FSSource(fsys, name)
+			if err != nil {
+				t.Fatalf("FSSource() unexpected error: %v", err)
+			}
+
+			var prov Provenance
+			config := &Config{}
+			err = ProcessWith("APP", config,
+				WithLookuper(MultiSource(MapSource(map[string]string{"APP_DATABASE_PORT": "6543"}), src)),
+				WithProvenance(&prov),
+			)
+			if err != nil {
+				t.Fatalf("ProcessWith() unexpected error: %v", err)
+			}
+
+			if config.Name != "svc" || config.Database.Host != "db.internal" {
+				t.Errorf("ProcessWith() = %+v", config)
+			}
+			if config.Database.Port != 6543 {
+				t.Errorf("Expected override to win over embedded default, got %d", config.Database.Port)
+			}
+			if got := prov["Database.Host"]; got.Source != EmbeddedSourceName || got.File != name {
+				t.Errorf("Provenance[Database.Host] = %+v", got)
+			}
+			if got := prov["Database.Port"]; got.Source != "map" {
+				t.Errorf("Provenance[Database.Port] = %+v", got)
+			}
+		})
+	}
+}
+
+func TestFSSourceIgnoresEnvironment(t *testing.T) {
+	t.Setenv("HOST", "from-env")
+	fsys := fstest.MapFS{"defaults.env": {Data: []byte("URL=http://${HOST}/\n")}}
+
+	src, err := FSSource(fsys, "defaults.env")
+	if err != nil {
+		t.Fatalf("FSSource() unexpected error: %v", err)
+	}
+	if v, _ := src.Lookup("URL"); v != "http:///" {
+		t.Errorf("Lookup(URL) = %q, want %q", v, "http:///")
+	}
+}
+
+func TestFSSourceMissing(t *testing.T) {
+	if _, err := FSSource(fstest.MapFS{}, "defaults.env"); err == nil {
+		t.Errorf("FSSource() expected error for missing file")
+	}
+}
@@ -1,3 +1,14 @@
 module github.com/justblue0312/envx
 
 go 1.24
+
+require (
+	filippo.io/age v1.2.1
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
@@ -0,0 +1,148 @@
+package envx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInterpolationCycle = errors.New("interpolation cycle")
+
+// WithInterpolation expands ${VAR}, ${VAR:-fallback} and ${VAR:?message}
+// references in values and `default` tags. Referenced keys are looked up
+// in the same source and expanded recursively. "$$" produces a literal "$".
+func WithInterpolation() Option {
+	return func(o *options) {
+		o.interpolate = true
+	}
+}
+
+type interpolator struct {
+	l     Lookuper
+	stack []string
+	cache map[string]string
+}
+
+func newInterpolator(l Lookuper) *interpolator {
+	return &interpolator{l: l, cache: make(map[string]string)}
+}
+
+func (in *interpolator) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '$' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := matchBrace(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated reference in %q", s)
+			}
+			v, err := in.reference(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i = end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// matchBrace returns the index of the brace closing the one at open.
+func matchBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (in *interpolator) reference(expr string) (string, error) {
+	name, op, arg := expr, "", ""
+	if i := strings.Index(expr, ":"); i >= 0 && i+1 < len(expr) && (expr[i+1] == '-' || expr[i+1] == '?') {
+		name, op, arg = expr[:i], expr[i:i+2], expr[i+2:]
+	}
+	if name == "" {
+		return "", fmt.Errorf("empty reference ${%s}", expr)
+	}
+
+	v, ok, err := in.resolve(name)
+	if err != nil {
+		return "", err
+	}
+	if ok && v != "" {
+		return v, nil
+	}
+
+	switch op {
+	case ":-":
+		return in.expand(arg)
+	case ":?":
+		msg, err := in.expand(arg)
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "required but not set"
+		}
+		return "", fmt.Errorf("%s: %s", name, msg)
+	}
+	return v, nil
+}
+
+func (in *interpolator) resolve(name string) (string, bool, error) {
+	if v, ok := in.cache[name]; ok {
+		return v, true, nil
+	}
+	for i, n := range in.stack {
+		if n == name {
+			cycle := append(append([]string{}, in.stack[i:]...), name)
+			return "", false, fmt.Errorf("%w: %s", ErrInterpolationCycle, strings.Join(cycle, " -> "))
+		}
+	}
+
+	raw, ok := in.l.Lookup(name)
+	if !ok {
+		return "", false, nil
+	}
+
+	in.stack = append(in.stack, name)
+	v, err := in.expand(raw)
+	in.stack = in.stack[:len(in.stack)-1]
+	if err != nil {
+		return "", false, err
+	}
+	in.cache[name] = v
+	return v, true, nil
+}
+
+// value expands a field value, treating key as the reference currently
+// being resolved so self references are reported as cycles.
+func (in *interpolator) value(key, s string) (string, error) {
+	if key != "" {
+		in.stack = append(in.stack, key)
+		defer func() { in.stack = in.stack[:len(in.stack)-1] }()
+	}
+	return in.expand(s)
+}
@@ -0,0 +1,114 @@
+package envx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInterpolation(t *testing.T) {
+	type Config struct {
+		URL       string `envx:"DATABASE_URL"`
+		Cache     string `envx:"CACHE_DIR" default:"${HOME}/.cache/app"`
+		Region    string `envx:"REGION" default:"${AWS_REGION:-us-east-1}"`
+		Price     string `envx:"PRICE"`
+		Nested    string `envx:"NESTED"`
+		Port      int    `envx:"PORT" default:"${DB_PORT}"`
+		Untouched string `envx:"UNTOUCHED"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{
+			"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}:${DB_PORT}/app",
+			"DB_USER":      "app",
+			"DB_HOST":      "${DB_HOST_NAME:-localhost}",
+			"DB_PORT":      "5432",
+			"HOME":         "/home/app",
+			"PRICE":        "$$5 and $1",
+			"NESTED":       "${MISSING:-${DB_USER}-fallback}",
+			"UNTOUCHED":    "no refs",
+		})),
+		WithInterpolation(),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		got, want string
+	}{
+		{config.URL, "postgres://app@localhost:5432/app"},
+		{config.Cache, "/home/app/.cache/app"},
+		{config.Region, "us-east-1"},
+		{config.Price, "$5 and $1"},
+		{config.Nested, "app-fallback"},
+		{config.Untouched, "no refs"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+	if config.Port != 5432 {
+		t.Errorf("Expected Port 5432, got %d", config.Port)
+	}
+}
+
+func TestInterpolationDisabled(t *testing.T) {
+	config := &TestConfig{}
+	err := ProcessMap("", config, map[string]string{"TEST_STRING": "${HOME}"})
+	if err != nil {
+		t.Fatalf("ProcessMap() unexpected error: %v", err)
+	}
+	if config.StringField != "${HOME}" {
+		t.Errorf("Expected value untouched without WithInterpolation, got %q", config.StringField)
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr error
+		msg     string
+	}{
+		{
+			name:    "cycle",
+			env:     map[string]string{"TEST_STRING": "${A}", "A": "${B}", "B": "${A}"},
+			wantErr: ErrInterpolationCycle,
+			msg:     "A -> B -> A",
+		},
+		{
+			name:    "self reference",
+			env:     map[string]string{"TEST_STRING": "x${TEST_STRING}"},
+			wantErr: ErrInterpolationCycle,
+			msg:     "TEST_STRING -> TEST_STRING",
+		},
+		{
+			name: "required reference",
+			env:  map[string]string{"TEST_STRING": "${TOKEN:?set TOKEN first}"},
+			msg:  "TOKEN: set TOKEN first",
+		},
+		{
+			name: "unterminated",
+			env:  map[string]string{"TEST_STRING": "${TOKEN"},
+			msg:  "unterminated reference",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProcessWith("", &TestConfig{}, WithLookuper(MapSource(tt.env)), WithInterpolation())
+			if err == nil {
+				t.Fatalf("ProcessWith() expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ProcessWith() expected %v, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("ProcessWith() error %q does not contain %q", err, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package envx
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithJSONVar makes Process read the variable name as a JSON document,
+// optionally base64 encoded, and flatten it onto the field keys the way
+// FileSource does. The document is consulted only for keys that are not
+// set individually. Keys may be given with or without the Process prefix,
+// so with prefix APP both {"db":{"host":"x"}} and {"app":{"db":{"host":"x"}}}
+// set APP_DB_HOST.
+func WithJSONVar(name string) Option {
+	return func(o *options) {
+		o.jsonVar = name
+	}
+}
+
+type jsonVarSource struct {
+	name   string
+	prefix string
+	values map[string]string
+	naming keyNaming
+}
+
+func newJSONVarSource(l Lookuper, name, prefix string, n keyNaming) (*jsonVarSource, error) {
+	raw, ok := l.Lookup(name)
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	doc := strings.TrimSpace(raw)
+	if !strings.HasPrefix(doc, "{") {
+		decoded, err := decodeBase64(doc)
+		if err != nil {
+			return nil, fmt.Errorf("envx: %s: value is neither JSON nor base64: %w", name, err)
+		}
+		doc = string(decoded)
+	}
+
+	values, err := ParseConfig(strings.NewReader(doc), FormatJSON)
+	if err != nil {
+		return nil, fmt.Errorf("envx: %s: %w", name, err)
+	}
+
+	return &jsonVarSource{name: name, prefix: n.keyPrefix(prefix), values: values, naming: n}, nil
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var b []byte
+		if b, err = enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, err
+}
+
+func (s *jsonVarSource) Lookup(key string) (string, bool) {
+	if v, ok := s.lookup(key); ok {
+		return v, true
+	}
+	if rest, ok := strings.CutPrefix(key, s.prefix); ok && s.prefix != "" {
+		return s.lookup(rest)
+	}
+	return "", false
+}
+
+// lookup reads key, written with the spec's separator, from the flattened
+// document.
+func (s *jsonVarSource) lookup(key string) (string, bool) {
+	if v, ok := s.values[key]; ok {
+		return v, true
+	}
+	v, ok := s.values[s.naming.flatKey(key)]
+	return v, ok
+}
+
+func (s *jsonVarSource) Keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		if s.prefix != "" && !strings.HasPrefix(k, s.prefix) {
+			k = s.prefix + k
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (*jsonVarSource) exactOnly() {}
+
+func (s *jsonVarSource) locate(o Origin) Origin {
+	o.Source = s.name
+	return o
+}
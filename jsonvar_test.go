@@ -0,0 +1,127 @@
+package envx
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestProcessWithJSONVar(t *testing.T) {
+	type Config struct {
+		Name string `envx:"NAME"`
+		DB   struct {
+			Host string   `envx:"HOST"`
+			Port int      `envx:"PORT"`
+			Tags []string `envx:"TAGS"`
+		} `envx:"DB"`
+	}
+
+	doc := `{"name": "svc", "db": {"host": "x", "port": 5432, "tags": ["a", "b"]}}`
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "json", value: doc},
+		{name: "prefixed json", value: `{"app": ` + doc + `}`},
+		{name: "base64", value: base64.StdEncoding.EncodeToString([]byte(doc))},
+		{name: "raw url base64", value: base64.RawURLEncoding.EncodeToString([]byte(doc))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prov Provenance
+			config := &Config{}
+			err := ProcessWith("APP", config,
+				WithLookuper(MapSource(map[string]string{
+					"APP_CONFIG_JSON": tt.value,
+					"APP_DB_PORT":     "6543",
+				})),
+				WithJSONVar("APP_CONFIG_JSON"),
+				WithProvenance(&prov),
+			)
+			if err != nil {
+				t.Fatalf("ProcessWith() unexpected error: %v", err)
+			}
+
+			if config.Name != "svc" || config.DB.Host != "x" {
+				t.Errorf("ProcessWith() = %+v", config)
+			}
+			if config.DB.Port != 6543 {
+				t.Errorf("Expected individual variable to win, got %d", config.DB.Port)
+			}
+			if len(config.DB.Tags) != 2 || config.DB.Tags[1] != "b" {
+				t.Errorf("Expected DB.Tags [a b], got %v", config.DB.Tags)
+			}
+			if got := prov["DB.Host"]; got.Source != "APP_CONFIG_JSON" {
+				t.Errorf("Provenance[DB.Host] = %+v", got)
+			}
+		})
+	}
+}
+
+func TestProcessWithJSONVarErrors(t *testing.T) {
+	type Config struct {
+		Host string `envx:"HOST" default:"localhost"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("", config, WithLookuper(MapSource(nil)), WithJSONVar("CONFIG_JSON"))
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error without the variable: %v", err)
+	}
+	if config.Host != "localhost" {
+		t.Errorf("Expected Host 'localhost', got %q", config.Host)
+	}
+
+	for _, value := range []string{`{"host":`, "not json!"} {
+		err := ProcessWith("", &Config{},
+			WithLookuper(MapSource(map[string]string{"CONFIG_JSON": value})),
+			WithJSONVar("CONFIG_JSON"),
+		)
+		if err == nil {
+			t.Errorf("ProcessWith() expected error for %q", value)
+		}
+	}
+}
+
+func TestCheckDisallowedJSONVar(t *testing.T) {
+	src := MapSource(map[string]string{"APP_CONFIG_JSON": "{}", "APP_TEST_STRING": "x"})
+	if err := CheckDisallowed("APP", &TestConfig{}, WithLookuper(src), WithJSONVar("APP_CONFIG_JSON")); err != nil {
+		t.Errorf("CheckDisallowed() unexpected error: %v", err)
+	}
+}
+
+func TestProcessWithJSONVarSeparator(t *testing.T) {
+	type Config struct {
+		Name string `envx:"NAME"`
+		DB   struct {
+			Host     string `envx:"HOST"`
+			MaxConns int    `envx:"MAX_CONNS"`
+		} `envx:"DB"`
+	}
+
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "double underscore", opt: DoubleUnderscore()},
+		{name: "dotted", opt: DottedKeys()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			err := ProcessWith("APP", config,
+				WithLookuper(MapSource(map[string]string{
+					"CONFIG_JSON": `{"name": "svc", "db": {"host": "x", "max_conns": 4}}`,
+				})),
+				WithJSONVar("CONFIG_JSON"),
+				tt.opt,
+			)
+			if err != nil {
+				t.Fatalf("ProcessWith() unexpected error: %v", err)
+			}
+			if config.Name != "svc" || config.DB.Host != "x" || config.DB.MaxConns != 4 {
+				t.Errorf("ProcessWith() = %+v", config)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package envx
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type namedSource struct {
+	name string
+	Lookuper
+}
+
+func (n namedSource) Keys() []string {
+	if lister, ok := n.Lookuper.(KeyLister); ok {
+		return lister.Keys()
+	}
+	return nil
+}
+
+// Named attaches a human readable name to l. The name is reported by
+// Provenance.
+func Named(name string, l Lookuper) Lookuper {
+	return namedSource{name: name, Lookuper: l}
+}
+
+type multiSource []Lookuper
+
+func (m multiSource) Lookup(key string) (string, bool) {
+	for _, l := range m {
+		if v, ok := l.Lookup(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Keys returns the union of the keys of every listable source.
+func (m multiSource) Keys() []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, l := range m {
+		lister, ok := l.(KeyLister)
+		if !ok {
+			continue
+		}
+		for _, k := range lister.Keys() {
+			if _, dup := seen[k]; dup {
+				continue
+			}
+			seen[k] = struct{}{}
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MultiSource combines several sources into one. Sources are consulted in
+// order and the first one holding a key wins.
+func MultiSource(sources ...Lookuper) Lookuper {
+	return multiSource(sources)
+}
+
+// DotenvCascade describes the dotenv files a Loader reads.
+type DotenvCascade struct {
+	// Dir is the directory the files are read from. Defaults to ".".
+	Dir string
+	// Env names the environment, e.g. "production". When empty it is read
+	// from EnvVar.
+	Env string
+	// EnvVar is the key holding the environment name. Defaults to APP_ENV.
+	EnvVar string
+	// Files lists file names from highest to lowest precedence. "{env}" is
+	// replaced by the environment name; files that reference it are skipped
+	// when no environment is set. Defaults to DefaultDotenvFiles.
+	Files []string
+}
+
+var DefaultDotenvFiles = []string{
+	".env.{env}.local",
+	".env.{env}",
+	".env.local",
+	".env",
+}
+
+type loaderLayer struct {
+	source  Lookuper
+	cascade *DotenvCascade
+}
+
+// Loader composes several sources with explicit precedence and processes a
+// spec against the merged view. Sources added first take precedence; the
+// `default` tag is only used when no source holds a key.
+type Loader struct {
+	prefix string
+	layers []loaderLayer
+	opts   []Option
+}
+
+func NewLoader(prefix string) *Loader {
+	return &Loader{prefix: prefix}
+}
+
+// WithSource adds a source below every source added so far.
+func (l *Loader) WithSource(name string, src Lookuper) *Loader {
+	l.layers = append(l.layers, loaderLayer{source: Named(name, src)})
+	return l
+}
+
+// WithEnv adds the process environment.
+func (l *Loader) WithEnv() *Loader {
+	return l.WithSource("env", OSSource())
+}
+
+// WithDotenv adds the dotenv cascade c. Missing files are skipped.
+func (l *Loader) WithDotenv(c DotenvCascade) *Loader {
+	l.layers = append(l.layers, loaderLayer{cascade: &c})
+	return l
+}
+
+// WithDefaults adds a map of fallback values below every source added so
+// far.
+func (l *Loader) WithDefaults(defaults map[string]string) *Loader {
+	return l.WithSource("defaults", MapSource(defaults))
+}
+
+// WithOptions sets options passed through to ProcessWith.
+func (l *Loader) WithOptions(opts ...Option) *Loader {
+	l.opts = append(l.opts, opts...)
+	return l
+}
+
+// Source builds the merged source without processing a spec.
+func (l *Loader) Source() (Lookuper, error) {
+	var merged multiSource
+	for _, layer := range l.layers {
+		if layer.cascade == nil {
+			merged = append(merged, layer.source)
+			continue
+		}
+		sources, err := layer.cascade.load(merged)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, sources...)
+	}
+	return merged, nil
+}
+
+func (l *Loader) Load(spec any) error {
+	src, err := l.Source()
+	if err != nil {
+		return err
+	}
+	opts := append([]Option{WithLookuper(src)}, l.opts...)
+	return ProcessWith(l.prefix, spec, opts...)
+}
+
+func (c *DotenvCascade) load(above Lookuper) ([]Lookuper, error) {
+	dir := c.Dir
+	if dir == "" {
+		dir = "."
+	}
+	files := c.Files
+	if files == nil {
+		files = DefaultDotenvFiles
+	}
+	env := c.Env
+	if env == "" {
+		envVar := c.EnvVar
+		if envVar == "" {
+			envVar = "APP_ENV"
+		}
+		env, _ = above.Lookup(envVar)
+	}
+
+	var sources []Lookuper
+	for _, name := range files {
+		if strings.Contains(name, "{env}") {
+			if env == "" {
+				continue
+			}
+			name = strings.ReplaceAll(name, "{env}", env)
+		}
+		path := filepath.Join(dir, name)
+		entries, err := readDotenvFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, Named(name, newDotenvSource(path, entries)))
+	}
+	return sources, nil
+}
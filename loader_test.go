@@ -0,0 +1,143 @@
+package envx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoaderPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".env":                  "APP_TEST_STRING=dotenv\nAPP_TEST_INT=1\nAPP_TEST_BOOL=false\nAPP_TEST_DEFAULT=dotenv\n",
+		".env.local":            "APP_TEST_INT=2\n",
+		".env.production":       "APP_TEST_INT=3\nAPP_TEST_BOOL=true\n",
+		".env.production.local": "APP_TEST_INT=4\n",
+	})
+
+	config := &TestConfig{}
+	err := NewLoader("APP").
+		WithSource("env", MapSource(map[string]string{
+			"APP_ENV":         "production",
+			"APP_TEST_STRING": "env",
+		})).
+		WithDotenv(DotenvCascade{Dir: dir}).
+		Load(config)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if config.StringField != "env" {
+		t.Errorf("Expected StringField 'env', got '%s'", config.StringField)
+	}
+	if config.IntField != 4 {
+		t.Errorf("Expected IntField 4, got %d", config.IntField)
+	}
+	if !config.BoolField {
+		t.Errorf("Expected BoolField true from .env.production")
+	}
+	if config.DefaultField != "dotenv" {
+		t.Errorf("Expected DefaultField 'dotenv', got '%s'", config.DefaultField)
+	}
+}
+
+func TestLoaderCascadeWithoutEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".env":            "APP_TEST_INT=1\n",
+		".env.production": "APP_TEST_INT=3\n",
+	})
+
+	config := &TestConfig{}
+	err := NewLoader("APP").
+		WithSource("env", MapSource(nil)).
+		WithDotenv(DotenvCascade{Dir: dir}).
+		Load(config)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if config.IntField != 1 {
+		t.Errorf("Expected IntField 1, got %d", config.IntField)
+	}
+}
+
+func TestLoaderCustomCascade(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base.env":    "APP_TEST_STRING=base\nAPP_TEST_INT=1\n",
+		"staging.env": "APP_TEST_INT=2\n",
+	})
+
+	config := &TestConfig{}
+	err := NewLoader("APP").
+		WithSource("env", MapSource(map[string]string{"STAGE": "staging"})).
+		WithDotenv(DotenvCascade{
+			Dir:    dir,
+			EnvVar: "STAGE",
+			Files:  []string{"{env}.env", "missing.env", "base.env"},
+		}).
+		Load(config)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if config.StringField != "base" || config.IntField != 2 {
+		t.Errorf("Load() = %+v", config)
+	}
+}
+
+func TestLoaderDefaults(t *testing.T) {
+	config := &TestConfig{}
+	err := NewLoader("").
+		WithSource("env", MapSource(map[string]string{"TEST_STRING": "env"})).
+		WithDefaults(map[string]string{"TEST_STRING": "fallback", "TEST_INT": "8"}).
+		Load(config)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if config.StringField != "env" || config.IntField != 8 {
+		t.Errorf("Load() = %+v", config)
+	}
+	if config.DefaultField != "default_value" {
+		t.Errorf("Expected default tag to apply, got '%s'", config.DefaultField)
+	}
+}
+
+func TestLoaderDotenvError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{".env": "BROKEN\n"})
+
+	err := NewLoader("").WithDotenv(DotenvCascade{Dir: dir}).Load(&TestConfig{})
+	if err == nil {
+		t.Fatalf("Load() expected error for malformed .env")
+	}
+}
+
+func TestMultiSourceKeys(t *testing.T) {
+	src := MultiSource(
+		MapSource(map[string]string{"B": "1", "A": "1"}),
+		LookuperFunc(func(string) (string, bool) { return "", false }),
+		MapSource(map[string]string{"A": "2", "C": "2"}),
+	)
+	keys := src.(KeyLister).Keys()
+	want := []string{"A", "B", "C"}
+	if len(keys) != len(want) {
+		t.Fatalf("Keys() = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("Keys() = %v, want %v", keys, want)
+		}
+	}
+	if v, _ := src.Lookup("A"); v != "1" {
+		t.Errorf("Lookup(A) = %q, want first source to win", v)
+	}
+}
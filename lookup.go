@@ -0,0 +1,326 @@
+package envx
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+var ErrNotListable = errors.New("lookup source cannot list its keys")
+
+// Lookuper is a source of key/value pairs that Process reads from.
+type Lookuper interface {
+	Lookup(key string) (string, bool)
+}
+
+// KeyLister is implemented by sources that can enumerate their keys.
+// CheckDisallowed requires it.
+type KeyLister interface {
+	Keys() []string
+}
+
+type LookuperFunc func(key string) (string, bool)
+
+func (f LookuperFunc) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+type osLookuper struct{}
+
+func (osLookuper) Lookup(key string) (string, bool) {
+	return lookupEnv(key)
+}
+
+func (osLookuper) Keys() []string {
+	return environKeys(os.Environ())
+}
+
+func (osLookuper) locate(o Origin) Origin {
+	o.Source = "env"
+	return o
+}
+
+// OSSource returns a Lookuper backed by the process environment.
+func OSSource() Lookuper {
+	return osLookuper{}
+}
+
+// envSnapshot is a copy of the process environment taken once per Process
+// call, so every field is resolved against the same consistent view.
+type envSnapshot struct {
+	values map[string]string
+	// folded indexes values by upper-cased key where the platform
+	// environment is case-insensitive.
+	folded map[string]string
+}
+
+func snapshotEnv() *envSnapshot {
+	environ := os.Environ()
+	s := &envSnapshot{values: make(map[string]string, len(environ))}
+	if envCaseInsensitive {
+		s.folded = make(map[string]string, len(environ))
+	}
+	for _, env := range environ {
+		k, v, ok := strings.Cut(env, "=")
+		if !ok || k == "" {
+			continue
+		}
+		s.values[k] = v
+		if s.folded != nil {
+			if _, dup := s.folded[strings.ToUpper(k)]; !dup {
+				s.folded[strings.ToUpper(k)] = v
+			}
+		}
+	}
+	return s
+}
+
+func (s *envSnapshot) Lookup(key string) (string, bool) {
+	if v, ok := s.values[key]; ok {
+		return v, true
+	}
+	if s.folded != nil {
+		v, ok := s.folded[strings.ToUpper(key)]
+		return v, ok
+	}
+	return "", false
+}
+
+func (s *envSnapshot) Keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (*envSnapshot) locate(o Origin) Origin {
+	o.Source = "env"
+	return o
+}
+
+// snapshotSources replaces every process environment source within l by a
+// single shared snapshot.
+func snapshotSources(l Lookuper) Lookuper {
+	var snap *envSnapshot
+	var walk func(Lookuper) Lookuper
+	walk = func(l Lookuper) Lookuper {
+		switch s := l.(type) {
+		case osLookuper:
+			if snap == nil {
+				snap = snapshotEnv()
+			}
+			return snap
+		case namedSource:
+			s.Lookuper = walk(s.Lookuper)
+			return s
+		case prefixSource:
+			s.Lookuper = walk(s.Lookuper)
+			return s
+		case multiSource:
+			sources := make(multiSource, len(s))
+			for i, inner := range s {
+				sources[i] = walk(inner)
+			}
+			return sources
+		}
+		return l
+	}
+	return walk(l)
+}
+
+type mapLookuper map[string]string
+
+func (m mapLookuper) Lookup(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (mapLookuper) locate(o Origin) Origin {
+	o.Source = "map"
+	return o
+}
+
+func (m mapLookuper) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MapSource returns a Lookuper backed by m.
+func MapSource(m map[string]string) Lookuper {
+	return mapLookuper(m)
+}
+
+// EnvironSource returns a Lookuper backed by a "KEY=value" slice such as
+// the result of os.Environ or an exec.Cmd's Env. Later entries win.
+func EnvironSource(environ []string) Lookuper {
+	m := make(map[string]string, len(environ))
+	for _, env := range environ {
+		k, v, ok := strings.Cut(env, "=")
+		if !ok || k == "" {
+			continue
+		}
+		m[k] = v
+	}
+	return mapLookuper(m)
+}
+
+func environKeys(environ []string) []string {
+	keys := make([]string, 0, len(environ))
+	for _, env := range environ {
+		k, _, _ := strings.Cut(env, "=")
+		if k == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type options struct {
+	lookuper        Lookuper
+	provenance      *Provenance
+	secretFiles     bool
+	secretFileMode  fs.FileMode
+	interpolate     bool
+	references      bool
+	resolvers       map[string]Resolver
+	ctx             context.Context
+	decrypt         bool
+	keyring         *Keyring
+	credentials     bool
+	jsonVar         string
+	caseInsensitive bool
+	strict          bool
+	allowedTags     map[string]bool
+	aliasWarning    func(alias, key string)
+	naming          keyNaming
+}
+
+type Option func(*options)
+
+// WithLookuper makes Process read values from l instead of the process
+// environment.
+func WithLookuper(l Lookuper) Option {
+	return func(o *options) {
+		o.lookuper = l
+	}
+}
+
+// Strict disables the fallback that looks up truncated keys, so DB_HOST
+// never falls back to DB. Use the `aliases` tag to name alternatives
+// explicitly. It also rejects struct tags envx does not know, except those
+// of common packages and those given to WithAllowedTags.
+func Strict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithAllowedTags lets fields carry the named tags under Strict.
+func WithAllowedTags(names ...string) Option {
+	return func(o *options) {
+		if o.allowedTags == nil {
+			o.allowedTags = make(map[string]bool)
+		}
+		for _, name := range names {
+			o.allowedTags[name] = true
+		}
+	}
+}
+
+// WithAliasWarning calls fn whenever a field is set from one of the names
+// in its `aliases` tag instead of its key, so uses of retired names can be
+// logged.
+func WithAliasWarning(fn func(alias, key string)) Option {
+	return func(o *options) {
+		o.aliasWarning = fn
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		lookuper:       OSSource(),
+		secretFileMode: DefaultSecretFileMode,
+		naming:         defaultNaming,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// exactSource is implemented by sources whose keys are derived from a
+// structured document. They never answer truncated keys, since the parent
+// of a nested key holds the whole object.
+type exactSource interface {
+	exactOnly()
+}
+
+// fallbackSource returns the part of l that tryNestedKeys may consult, or
+// nil when there is none.
+func fallbackSource(l Lookuper) Lookuper {
+	switch s := l.(type) {
+	case exactSource:
+		return nil
+	case namedSource:
+		if inner := fallbackSource(s.Lookuper); inner != nil {
+			return namedSource{name: s.name, Lookuper: inner}
+		}
+		return nil
+	case fsSource:
+		if fallbackSource(s.Lookuper) == nil {
+			return nil
+		}
+		return s
+	case foldSource:
+		if inner := fallbackSource(s.Lookuper); inner != nil {
+			return foldSource{Lookuper: inner, index: s.index}
+		}
+		return nil
+	case prefixSource:
+		if inner := fallbackSource(s.Lookuper); inner != nil {
+			return prefixSource{prefix: s.prefix, Lookuper: inner}
+		}
+		return nil
+	case multiSource:
+		var filtered multiSource
+		for _, inner := range s {
+			if inner = fallbackSource(inner); inner != nil {
+				filtered = append(filtered, inner)
+			}
+		}
+		if filtered == nil {
+			return nil
+		}
+		return filtered
+	}
+	return l
+}
+
+func tryNestedKeys(l Lookuper, key, sep string) (string, string) {
+	if !strings.Contains(key, sep) {
+		return "", ""
+	}
+	if l = fallbackSource(l); l == nil {
+		return "", ""
+	}
+
+	parts := strings.Split(key, sep)
+	for i := len(parts); i > 1; i-- {
+		testKey := strings.Join(parts[:i], sep)
+		if value, ok := l.Lookup(testKey); ok && value != "" {
+			return testKey, value
+		}
+	}
+
+	return "", ""
+}
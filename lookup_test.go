@@ -0,0 +1,218 @@
+package envx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestProcessMap(t *testing.T) {
+	config := &TestConfig{}
+	err := ProcessMap("APP", config, map[string]string{
+		"APP_TEST_STRING": "from map",
+		"APP_TEST_INT":    "7",
+	})
+	if err != nil {
+		t.Fatalf("ProcessMap() unexpected error: %v", err)
+	}
+
+	if config.StringField != "from map" {
+		t.Errorf("Expected StringField 'from map', got '%s'", config.StringField)
+	}
+	if config.IntField != 7 {
+		t.Errorf("Expected IntField 7, got %d", config.IntField)
+	}
+	if config.DefaultField != "default_value" {
+		t.Errorf("Expected DefaultField 'default_value', got '%s'", config.DefaultField)
+	}
+}
+
+func TestProcessEnviron(t *testing.T) {
+	environ := []string{
+		"TEST_STRING=first",
+		"TEST_BOOL=true",
+		"malformed",
+		"TEST_STRING=second=with=equals",
+	}
+
+	config := &TestConfig{}
+	if err := ProcessEnviron("", config, environ); err != nil {
+		t.Fatalf("ProcessEnviron() unexpected error: %v", err)
+	}
+
+	if config.StringField != "second=with=equals" {
+		t.Errorf("Expected StringField 'second=with=equals', got '%s'", config.StringField)
+	}
+	if !config.BoolField {
+		t.Errorf("Expected BoolField true")
+	}
+}
+
+func TestProcessWithNestedFallback(t *testing.T) {
+	type Config struct {
+		Database struct {
+			Host string `envx:"PRIMARY_HOST"`
+		} `envx:"DB"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("", config, WithLookuper(MapSource(map[string]string{
+		"DB_PRIMARY": "fallback",
+	})))
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if config.Database.Host != "fallback" {
+		t.Errorf("Expected Database.Host 'fallback', got '%s'", config.Database.Host)
+	}
+}
+
+func TestProcessWithLookuperFunc(t *testing.T) {
+	var asked []string
+	l := LookuperFunc(func(key string) (string, bool) {
+		asked = append(asked, key)
+		if key == "TEST_INT" {
+			return "99", true
+		}
+		return "", false
+	})
+
+	config := &TestConfig{}
+	if err := ProcessWith("", config, WithLookuper(l)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if config.IntField != 99 {
+		t.Errorf("Expected IntField 99, got %d", config.IntField)
+	}
+	if len(asked) == 0 {
+		t.Errorf("Expected lookuper to be consulted")
+	}
+}
+
+func TestCheckDisallowedWithLookuper(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  Lookuper
+		wantErr error
+		anyErr  bool
+	}{
+		{
+			name:   "all known",
+			source: MapSource(map[string]string{"APP_TEST_STRING": "x", "OTHER": "y"}),
+		},
+		{
+			name:   "unknown key",
+			source: MapSource(map[string]string{"APP_TEST_STRING": "x", "APP_UNKNOWN": "y"}),
+			anyErr: true,
+		},
+		{
+			name:   "environ source",
+			source: EnvironSource([]string{"APP_TEST_INT=1", "APP_NOPE=2"}),
+			anyErr: true,
+		},
+		{
+			name: "not listable",
+			source: LookuperFunc(func(string) (string, bool) {
+				return "", false
+			}),
+			wantErr: ErrNotListable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDisallowed("APP", &TestConfig{}, WithLookuper(tt.source))
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("CheckDisallowed() expected %v, got %v", tt.wantErr, err)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Errorf("CheckDisallowed() expected error, got nil")
+				}
+			default:
+				if err != nil {
+					t.Errorf("CheckDisallowed() unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+type setenvDecoder string
+
+func (d *setenvDecoder) Decode(value string) error {
+	*d = setenvDecoder(value)
+	return os.Setenv("SNAP_SECOND", "changed")
+}
+
+func TestProcessSnapshotsEnvironment(t *testing.T) {
+	t.Setenv("SNAP_FIRST", "first")
+	t.Setenv("SNAP_SECOND", "original")
+
+	var config struct {
+		First  setenvDecoder `envx:"FIRST"`
+		Second string        `envx:"SECOND"`
+	}
+	if err := Process("SNAP", &config); err != nil {
+		t.Fatalf("Process() unexpected error: %v", err)
+	}
+
+	if config.Second != "original" {
+		t.Errorf("Expected Second from the snapshot 'original', got %q", config.Second)
+	}
+	if v := os.Getenv("SNAP_SECOND"); v != "changed" {
+		t.Errorf("Expected decoder to change SNAP_SECOND, got %q", v)
+	}
+}
+
+// largeSpec returns a pointer to a struct with n string fields named F0..Fn
+// and sets BENCH_F<i> for every other field.
+func largeSpec(b *testing.B, n int) func() any {
+	fields := make([]reflect.StructField, n)
+	for i := range fields {
+		name := "F" + strconv.Itoa(i)
+		fields[i] = reflect.StructField{
+			Name: name,
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(`envx:"` + name + `"`),
+		}
+		if i%2 == 0 {
+			b.Setenv("BENCH_"+name, "value")
+		}
+	}
+	typ := reflect.StructOf(fields)
+	return func() any {
+		return reflect.New(typ).Interface()
+	}
+}
+
+func BenchmarkProcessLargeSpec(b *testing.B) {
+	for _, n := range []int{10, 100, 500} {
+		b.Run(fmt.Sprintf("fields=%d", n), func(b *testing.B) {
+			spec := largeSpec(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := Process("BENCH", spec()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProcessLargeSpecCaseInsensitive(b *testing.B) {
+	spec := largeSpec(b, 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ProcessWith("BENCH", spec(), CaseInsensitive()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
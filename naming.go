@@ -0,0 +1,149 @@
+package envx
+
+import (
+	"reflect"
+	"strings"
+)
+
+// NameMapper turns a field name into the key segment used for it. It is
+// not applied to fields with an `envx` tag.
+type NameMapper func(field string) string
+
+// AsIs uses field names unchanged. It is the default.
+func AsIs(field string) string {
+	return field
+}
+
+// SnakeCase splits field names into words, as the `split_words` tag does
+// for a single field: DatabaseURL becomes DATABASE_URL.
+func SnakeCase(field string) string {
+	return toSnakeCase(field)
+}
+
+// WithNameMapper derives the key of every untagged field with m.
+func WithNameMapper(m NameMapper) Option {
+	return func(o *options) {
+		o.naming.mapper = m
+	}
+}
+
+// WithSeparator sets the string joining the prefix and the keys of nested
+// structs. The default is "_".
+func WithSeparator(sep string) Option {
+	return func(o *options) {
+		o.naming.sep = sep
+	}
+}
+
+// DoubleUnderscore joins nested keys with "__", as in APP__DB__HOST. The
+// words of a single field are still joined with "_".
+func DoubleUnderscore() Option {
+	return WithSeparator("__")
+}
+
+// DottedKeys derives lower-case keys joined with ".", as in app.db.host.
+// FileSource answers dotted keys, so this reads configuration files in
+// their own naming.
+func DottedKeys() Option {
+	return func(o *options) {
+		o.naming.sep = "."
+		o.naming.lower = true
+	}
+}
+
+type keyNaming struct {
+	mapper  NameMapper
+	sep     string
+	lower   bool
+	dialect Dialect
+}
+
+var defaultNaming = keyNaming{sep: "_"}
+
+func (n keyNaming) separator() string {
+	if n.sep == "" {
+		return "_"
+	}
+	return n.sep
+}
+
+func (n keyNaming) casing(s string) string {
+	if n.lower {
+		return strings.ToLower(s)
+	}
+	return strings.ToUpper(s)
+}
+
+func (n keyNaming) field(name string, splitWords bool) string {
+	switch {
+	case splitWords:
+		return toSnakeCase(name)
+	case n.mapper != nil:
+		return n.mapper(name)
+	}
+	return name
+}
+
+func (n keyNaming) join(prefix, key string) string {
+	if prefix == "" {
+		return n.casing(key)
+	}
+	return n.casing(prefix + n.separator() + key)
+}
+
+// keyPrefix returns the start shared by every key under the prefix given
+// to Process. DialectEnv keeps the prefix as written and adds "_" unless it
+// already ends with one, so both "APP" and caarlos0/env's "APP_" work.
+func (n keyNaming) keyPrefix(prefix string) string {
+	switch {
+	case prefix == "":
+		return ""
+	case n.dialect == DialectEnv:
+		if strings.HasSuffix(prefix, "_") {
+			return prefix
+		}
+		return prefix + "_"
+	}
+	return n.casing(prefix + n.separator())
+}
+
+// flatKey returns key as flattenConfig writes it: the parts of a document
+// path are always joined with "_", whatever separator the spec uses.
+func (n keyNaming) flatKey(key string) string {
+	parts := strings.Split(key, n.separator())
+	for i, part := range parts {
+		parts[i] = FileNameToKey(part)
+	}
+	return strings.Join(parts, "_")
+}
+
+// prefixed returns key under prefix. DialectEnv concatenates the two, as
+// caarlos0/env does.
+func (n keyNaming) prefixed(prefix, key string) string {
+	if n.dialect == DialectEnv {
+		return prefix + key
+	}
+	return n.join(prefix, key)
+}
+
+// cacheID identifies n in the plan cache. Custom mappers cannot be
+// compared, so plans using them are not cached.
+func (n keyNaming) cacheID() (string, bool) {
+	id := n.dialect.String() + "," + n.separator()
+	if n.lower {
+		id += ",lower"
+	}
+	switch {
+	case n.mapper == nil:
+	case sameFunc(n.mapper, AsIs):
+	case sameFunc(n.mapper, SnakeCase):
+		id += ",snake"
+	default:
+		return "", false
+	}
+	return id, true
+}
+
+func sameFunc(a, b NameMapper) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
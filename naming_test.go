@@ -0,0 +1,133 @@
+package envx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type namingConfig struct {
+	LogLevel string
+	Database struct {
+		HostName string
+		Port     int `envx:"PORT"`
+	}
+}
+
+func TestProcessWithNaming(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		source map[string]string
+	}{
+		{
+			name: "as is",
+			source: map[string]string{
+				"APP_LOGLEVEL":          "debug",
+				"APP_DATABASE_HOSTNAME": "db",
+				"APP_DATABASE_PORT":     "5432",
+			},
+		},
+		{
+			name: "snake case",
+			opts: []Option{WithNameMapper(SnakeCase)},
+			source: map[string]string{
+				"APP_LOG_LEVEL":          "debug",
+				"APP_DATABASE_HOST_NAME": "db",
+				"APP_DATABASE_PORT":      "5432",
+			},
+		},
+		{
+			name: "double underscore",
+			opts: []Option{WithNameMapper(SnakeCase), DoubleUnderscore()},
+			source: map[string]string{
+				"APP__LOG_LEVEL":           "debug",
+				"APP__DATABASE__HOST_NAME": "db",
+				"APP__DATABASE__PORT":      "5432",
+			},
+		},
+		{
+			name: "dotted",
+			opts: []Option{WithNameMapper(SnakeCase), DottedKeys()},
+			source: map[string]string{
+				"app.log_level":          "debug",
+				"app.database.host_name": "db",
+				"app.database.port":      "5432",
+			},
+		},
+		{
+			name: "custom mapper",
+			opts: []Option{WithNameMapper(func(field string) string { return "X" + field })},
+			source: map[string]string{
+				"APP_XLOGLEVEL":           "debug",
+				"APP_XDATABASE_XHOSTNAME": "db",
+				"APP_XDATABASE_PORT":      "5432",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &namingConfig{}
+			opts := append([]Option{WithLookuper(MapSource(tt.source))}, tt.opts...)
+			if err := ProcessWith("APP", config, opts...); err != nil {
+				t.Fatalf("ProcessWith() unexpected error: %v", err)
+			}
+
+			if config.LogLevel != "debug" || config.Database.HostName != "db" || config.Database.Port != 5432 {
+				t.Errorf("ProcessWith() = %+v", config)
+			}
+			if err := CheckDisallowed("APP", config, opts...); err != nil {
+				t.Errorf("CheckDisallowed() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessWithSeparatorNestedFallback(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host string `envx:"HOST_PRIMARY"`
+		}
+	}
+
+	config := &Config{}
+	src := MapSource(map[string]string{"APP__DB__HOST": "fallback"})
+	if err := ProcessWith("APP", config, WithLookuper(src), DoubleUnderscore()); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.DB.Host != "" {
+		t.Errorf("Expected no fallback across a single separator, got %q", config.DB.Host)
+	}
+
+	src = MapSource(map[string]string{"APP__DB": "fallback"})
+	if err := ProcessWith("APP", config, WithLookuper(src), DoubleUnderscore()); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.DB.Host != "fallback" {
+		t.Errorf("Expected fallback to APP__DB, got %q", config.DB.Host)
+	}
+}
+
+func TestFileSourceDottedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeSecret(t, filepath.Dir(path), filepath.Base(path), strings.Join([]string{
+		"log_level: debug",
+		"database:",
+		"  host_name: db",
+		"  port: 5432",
+	}, "\n"), 0o644)
+
+	src, err := FileSource(path)
+	if err != nil {
+		t.Fatalf("FileSource() unexpected error: %v", err)
+	}
+
+	config := &namingConfig{}
+	if err := ProcessWith("", config, WithLookuper(src), WithNameMapper(SnakeCase), DottedKeys()); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.LogLevel != "debug" || config.Database.HostName != "db" || config.Database.Port != 5432 {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+}
@@ -0,0 +1,115 @@
+package envx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type NestingDB struct {
+	Host string `envx:"HOST"`
+	Port int    `envx:"PORT"`
+}
+
+type NestingCommon struct {
+	Region string `envx:"REGION"`
+}
+
+// nestingPair decodes "a:b" itself but also has fields of its own.
+type nestingPair struct {
+	A string `envx:"A"`
+	B string `envx:"B"`
+}
+
+func (p *nestingPair) UnmarshalText(text []byte) error {
+	p.A, p.B, _ = strings.Cut(string(text), ":")
+	return nil
+}
+
+func TestGatherInfoNestingTags(t *testing.T) {
+	type Config struct {
+		NestingCommon
+		Tenant   *NestingCommon `prefix:"TENANT"`
+		Primary  NestingDB      `envx:"DB"`
+		Replica  NestingDB      `envx:"REPLICA" prefix:"PG"`
+		Cache    NestingDB      `squash:"true"`
+		Global   NestingDB      `envx:"GLOBAL" noprefix:"true"`
+		Home     string         `envx:"HOME_DIR" noprefix:"true"`
+		Pair     nestingPair    `envx:"PAIR"`
+		Expanded nestingPair    `envx:"EXPANDED" nested:"true"`
+	}
+
+	infos, err := gatherInfo("APP", &Config{}, defaultNaming)
+	if err != nil {
+		t.Fatalf("gatherInfo() unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"NestingCommon.Region": "APP_REGION",
+		"Tenant.Region":        "APP_TENANT_REGION",
+		"Primary.Host":         "APP_DB_HOST",
+		"Primary.Port":         "APP_DB_PORT",
+		"Replica.Host":         "APP_PG_HOST",
+		"Replica.Port":         "APP_PG_PORT",
+		"Cache.Host":           "APP_HOST",
+		"Cache.Port":           "APP_PORT",
+		"Global.Host":          "GLOBAL_HOST",
+		"Global.Port":          "GLOBAL_PORT",
+		"Home":                 "HOME_DIR",
+		"Pair":                 "APP_PAIR",
+		"Expanded.A":           "APP_EXPANDED_A",
+		"Expanded.B":           "APP_EXPANDED_B",
+	}
+	got := make(map[string]string, len(infos))
+	for _, info := range infos {
+		got[info.Path] = info.Key
+	}
+	for path, key := range want {
+		if got[path] != key {
+			t.Errorf("gatherInfo() key of %s = %q, want %q", path, got[path], key)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("gatherInfo() returned %d fields, want %d: %v", len(got), len(want), got)
+	}
+}
+
+func TestGatherInfoNestedFalseNeedsDecoder(t *testing.T) {
+	type Config struct {
+		Flat NestingDB `envx:"FLAT" nested:"false"`
+	}
+
+	_, err := gatherInfo("APP", &Config{}, defaultNaming)
+	if !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("gatherInfo() expected ErrInvalidTag, got %v", err)
+	}
+}
+
+func TestProcessEmbeddedPrefixTags(t *testing.T) {
+	type Base struct {
+		NestingDB `prefix:"PG"`
+		Name      string `envx:"NAME"`
+	}
+	type Config struct {
+		*Base `squash:"true"`
+		Pair  nestingPair `envx:"PAIR"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("APP", config, WithLookuper(MapSource(map[string]string{
+		"APP_PG_HOST": "db",
+		"APP_PG_PORT": "5432",
+		"APP_NAME":    "svc",
+		"APP_PAIR":    "x:y",
+	})))
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if config.Host != "db" || config.Port != 5432 || config.Name != "svc" {
+		t.Errorf("ProcessWith() = %+v", config.Base)
+	}
+	if config.Pair.A != "x" || config.Pair.B != "y" {
+		t.Errorf("Expected Pair decoded as a whole, got %+v", config.Pair)
+	}
+}
@@ -0,0 +1,212 @@
+package envx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// fieldPlan is the part of a varInfo that only depends on the spec type.
+type fieldPlan struct {
+	Name string
+	Path string
+	Alt  string
+	// Key is relative to the prefix given to Process, unless Absolute.
+	Key      string
+	Absolute bool
+	Opts     fieldTags
+	// Aliases lists the `aliases` tag names in order, cased like keys.
+	Aliases []string
+	// Index is the sequence of field indexes leading to the field from the
+	// root struct, as for reflect.Value.FieldByIndex.
+	Index  []int
+	Decode decodeMethod
+	// Unknown lists the unknown tags of the field and of the structs
+	// enclosing it.
+	Unknown []unknownTag
+}
+
+type planKey struct {
+	typ    reflect.Type
+	naming string
+}
+
+// plans caches the compiled plan of every spec type Process has seen. Keys
+// are stored without the prefix, so callers using one prefix per tenant
+// share a single entry.
+var plans sync.Map // planKey -> []fieldPlan
+
+func planFor(typ reflect.Type, n keyNaming) ([]fieldPlan, error) {
+	id, cacheable := n.cacheID()
+	if !cacheable {
+		return compilePlan(typ, n)
+	}
+
+	key := planKey{typ: typ, naming: id}
+	if plan, ok := plans.Load(key); ok {
+		return plan.([]fieldPlan), nil
+	}
+	plan, err := compilePlan(typ, n)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := plans.LoadOrStore(key, plan)
+	return actual.([]fieldPlan), nil
+}
+
+func compilePlan(typ reflect.Type, n keyNaming) ([]fieldPlan, error) {
+	plan := make([]fieldPlan, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+
+		if !fieldType.IsExported() {
+			continue
+		}
+		opts, err := parseTags(fieldType.Tag, n.dialect)
+		if err != nil {
+			return nil, fmt.Errorf("envx: field %s of %s: %w", fieldType.Name, typ, err)
+		}
+		if opts.Ignored {
+			continue
+		}
+
+		p := fieldPlan{
+			Name:  fieldType.Name,
+			Path:  fieldType.Name,
+			Opts:  opts,
+			Index: []int{i},
+		}
+		for _, tag := range opts.Unknown {
+			p.Unknown = append(p.Unknown, unknownTag{Path: p.Path, Tag: tag})
+		}
+		elem := structElem(fieldType.Type)
+		if elem != nil && opts.Nested != "" && !isTrue(opts.Nested) && decodeMethodOf(elem) == decodeKind {
+			return nil, fmt.Errorf("envx: field %s of %s: %w: nested:%q but %s does not decode itself",
+				fieldType.Name, typ, ErrInvalidTag, opts.Nested, elem)
+		}
+
+		if n.dialect == DialectEnv {
+			// caarlos0/env uses keys as written and concatenates prefixes.
+			p.Key = opts.Name
+			if elem != nil && isNested(elem, opts) {
+				inner, err := planFor(elem, n)
+				if err != nil {
+					return nil, err
+				}
+				plan = appendInner(plan, p, inner, opts.Prefix, false, n)
+				continue
+			}
+			if opts.Name != "" {
+				p.Decode = decodeMethodOf(leafType(fieldType.Type))
+				plan = append(plan, p)
+			}
+			continue
+		}
+
+		p.Alt = n.casing(opts.Name)
+
+		for _, alias := range opts.Aliases {
+			p.Aliases = append(p.Aliases, n.casing(alias))
+		}
+
+		p.Key = n.field(p.Name, opts.SplitWords)
+		if p.Alt != "" {
+			p.Key = p.Alt
+		}
+		p.Key = n.casing(p.Key)
+		p.Absolute = opts.NoPrefix
+
+		if elem != nil && isNested(elem, opts) {
+			innerPrefix := p.Key
+			switch {
+			case opts.Prefix != "":
+				innerPrefix = n.casing(opts.Prefix)
+			case opts.Squash, fieldType.Anonymous:
+				innerPrefix = ""
+			}
+
+			inner, err := planFor(elem, n)
+			if err != nil {
+				return nil, err
+			}
+			plan = appendInner(plan, p, inner, innerPrefix, p.Absolute, n)
+			continue
+		}
+		p.Decode = decodeMethodOf(leafType(fieldType.Type))
+		plan = append(plan, p)
+	}
+	return plan, nil
+}
+
+// appendInner appends the plan of the struct field p, whose fields are
+// keyed under prefix, to plan.
+func appendInner(plan []fieldPlan, p fieldPlan, inner []fieldPlan, prefix string, absolute bool, n keyNaming) []fieldPlan {
+	for _, ip := range inner {
+		ip.Path = p.Path + "." + ip.Path
+		ip.Index = append(append([]int(nil), p.Index...), ip.Index...)
+		unknown := append([]unknownTag(nil), p.Unknown...)
+		for _, u := range ip.Unknown {
+			unknown = append(unknown, unknownTag{Path: p.Path + "." + u.Path, Tag: u.Tag})
+		}
+		ip.Unknown = unknown
+		if !ip.Absolute {
+			ip.Key = n.prefixed(prefix, ip.Key)
+			ip.Absolute = absolute
+		}
+		plan = append(plan, ip)
+	}
+	return plan
+}
+
+// isNested reports whether the fields of the struct type t are processed
+// individually. By default that is the case unless t decodes itself; the
+// `nested` tag overrides it either way.
+func isNested(t reflect.Type, opts fieldTags) bool {
+	if opts.Nested != "" {
+		return isTrue(opts.Nested)
+	}
+	return decodeMethodOf(t) == decodeKind
+}
+
+// structElem returns the struct type t refers to, directly or through
+// pointers, or nil.
+func structElem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		if t.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// leafType returns the type of the value fieldPlan.field returns for a
+// field of type t.
+func leafType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		t = t.Elem()
+	}
+	return t
+}
+
+// field returns the field of root p describes, allocating nil struct
+// pointers on the way.
+func (p fieldPlan) field(root reflect.Value) reflect.Value {
+	field := root
+	for _, i := range p.Index {
+		field = field.Field(i)
+		for field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				if field.Type().Elem().Kind() != reflect.Struct {
+					break
+				}
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+	}
+	return field
+}
@@ -0,0 +1,124 @@
+package envx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestPlanForSharedAcrossPrefixes(t *testing.T) {
+	type Config struct {
+		Host string `envx:"HOST"`
+	}
+	typ := reflect.TypeOf(Config{})
+
+	for i := 0; i < 100; i++ {
+		infos, err := gatherInfo(fmt.Sprintf("TENANT%d", i), &Config{}, defaultNaming)
+		if err != nil {
+			t.Fatalf("gatherInfo() unexpected error: %v", err)
+		}
+		if want := fmt.Sprintf("TENANT%d_HOST", i); infos[0].Key != want {
+			t.Errorf("gatherInfo() key = %s, want %s", infos[0].Key, want)
+		}
+	}
+
+	entries := 0
+	plans.Range(func(k, _ any) bool {
+		if k.(planKey).typ == typ {
+			entries++
+		}
+		return true
+	})
+	if entries != 1 {
+		t.Errorf("Expected one cached plan for all prefixes, got %d", entries)
+	}
+}
+
+func TestPlanDecodeMethod(t *testing.T) {
+	type Config struct {
+		Decoder *CustomType              `envx:"DECODER" nested:"false"`
+		Text    CustomUnmarshaler        `envx:"TEXT"`
+		Binary  *CustomBinaryUnmarshaler `envx:"BINARY"`
+		Int     *int                     `envx:"INT"`
+	}
+
+	plan, err := compilePlan(reflect.TypeOf(Config{}), defaultNaming)
+	if err != nil {
+		t.Fatalf("compilePlan() unexpected error: %v", err)
+	}
+	want := []decodeMethod{decodeDecoder, decodeText, decodeBinary, decodeKind}
+	for i, p := range plan {
+		if p.Decode != want[i] {
+			t.Errorf("compilePlan() %s decodes with %d, want %d", p.Name, p.Decode, want[i])
+		}
+	}
+}
+
+func TestGatherInfoAllocatesPerValue(t *testing.T) {
+	type Inner struct {
+		Host string `envx:"HOST"`
+	}
+	type Config struct {
+		DB *Inner `envx:"DB"`
+	}
+
+	a, b := &Config{}, &Config{}
+	for _, c := range []*Config{a, b} {
+		infos, err := gatherInfo("APP", c, defaultNaming)
+		if err != nil {
+			t.Fatalf("gatherInfo() unexpected error: %v", err)
+		}
+		if len(infos) != 1 || infos[0].Key != "APP_DB_HOST" {
+			t.Fatalf("gatherInfo() = %+v", infos)
+		}
+		infos[0].Field.SetString("set")
+	}
+	if a.DB == b.DB || a.DB.Host != "set" || b.DB.Host != "set" {
+		t.Errorf("gatherInfo() did not bind fields of each value: %+v %+v", a.DB, b.DB)
+	}
+}
+
+func TestProcessConcurrent(t *testing.T) {
+	src := MapSource(map[string]string{"TEST_STRING": "x", "TEST_INT": "3", "TEST_REQUIRED": "y"})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config := &TestConfig{}
+			if err := ProcessWith("", config, WithLookuper(src)); err != nil {
+				errs <- err
+				return
+			}
+			if config.IntField != 3 {
+				t.Errorf("Expected IntField 3, got %d", config.IntField)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("ProcessWith() unexpected error: %v", err)
+	}
+}
+
+func BenchmarkGatherInfo(b *testing.B) {
+	config := &TestConfig{}
+	for i := 0; i < b.N; i++ {
+		if _, err := gatherInfo("APP", config, defaultNaming); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompilePlan(b *testing.B) {
+	typ := reflect.TypeOf(TestConfig{})
+	for i := 0; i < b.N; i++ {
+		if _, err := compilePlan(typ, defaultNaming); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
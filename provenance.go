@@ -0,0 +1,147 @@
+package envx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type OriginKind int
+
+const (
+	// FromKey means the value was found under the field's full key.
+	FromKey OriginKind = iota
+	// FromAlt means the value was found under the bare `envx` tag name.
+	FromAlt
+	// FromNested means the value was found by truncating the key.
+	FromNested
+	// FromDefault means the `default` tag was applied.
+	FromDefault
+	// FromAlias means the value was found under a name from the `aliases`
+	// tag.
+	FromAlias
+)
+
+func (k OriginKind) String() string {
+	switch k {
+	case FromKey:
+		return "key"
+	case FromAlt:
+		return "alt"
+	case FromNested:
+		return "nested"
+	case FromDefault:
+		return "default"
+	case FromAlias:
+		return "alias"
+	}
+	return fmt.Sprintf("OriginKind(%d)", int(k))
+}
+
+// Origin describes where the value of a single field came from.
+type Origin struct {
+	Key    string
+	Kind   OriginKind
+	Source string
+	File   string
+	Line   int
+	// SecretFile is the path the value was read from when the key named a
+	// secret file.
+	SecretFile string
+}
+
+func (o Origin) String() string {
+	var b strings.Builder
+	if o.Key != "" {
+		b.WriteString(o.Key)
+		b.WriteString(" ")
+	}
+	fmt.Fprintf(&b, "(%s) from %s", o.Kind, o.Source)
+	switch {
+	case o.File != "" && o.Line > 0:
+		fmt.Fprintf(&b, " at %s:%d", o.File, o.Line)
+	case o.File != "":
+		fmt.Fprintf(&b, " at %s", o.File)
+	}
+	if o.SecretFile != "" {
+		fmt.Fprintf(&b, " via %s", o.SecretFile)
+	}
+	return b.String()
+}
+
+// Provenance maps field paths such as "Database.Host" to the origin of
+// their value. Fields left untouched by Process are absent.
+type Provenance map[string]Origin
+
+func (p Provenance) String() string {
+	paths := make([]string, 0, len(p))
+	for path := range p {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s: %s\n", path, p[path])
+	}
+	return b.String()
+}
+
+// WithProvenance records the origin of every field Process sets into p.
+// A nil p records nothing.
+func WithProvenance(p *Provenance) Option {
+	return func(o *options) {
+		if p == nil {
+			return
+		}
+		if *p == nil {
+			*p = make(Provenance)
+		}
+		o.provenance = p
+	}
+}
+
+// locator is implemented by sources that can describe where a key they
+// hold comes from.
+type locator interface {
+	locate(o Origin) Origin
+}
+
+// Locator can be implemented by sources outside this package to describe
+// where a key they hold comes from.
+type Locator interface {
+	Locate(o Origin) Origin
+}
+
+func locate(l Lookuper, o Origin) Origin {
+	if loc, ok := l.(locator); ok {
+		return loc.locate(o)
+	}
+	if loc, ok := l.(Locator); ok {
+		return loc.Locate(o)
+	}
+	o.Source = fmt.Sprintf("%T", l)
+	return o
+}
+
+func (n namedSource) locate(o Origin) Origin {
+	o = locate(n.Lookuper, o)
+	o.Source = n.name
+	return o
+}
+
+func (m multiSource) locate(o Origin) Origin {
+	for _, l := range m {
+		if _, ok := l.Lookup(o.Key); ok {
+			return locate(l, o)
+		}
+	}
+	return o
+}
+
+func (d *dotenvSource) locate(o Origin) Origin {
+	o.Source = d.file
+	o.File = d.file
+	o.Line = d.lines[o.Key]
+	return o
+}
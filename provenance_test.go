@@ -0,0 +1,105 @@
+package envx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	type Config struct {
+		Name     string `envx:"NAME"`
+		Database struct {
+			Host string `envx:"HOST"`
+			Port int    `envx:"PORT" default:"5432"`
+			User string `envx:"PRIMARY_USER"`
+		} `envx:"DB"`
+		Token   string `envx:"TOKEN"`
+		Missing string `envx:"MISSING"`
+	}
+
+	var prov Provenance
+	config := &Config{}
+	err := ProcessWith("APP", config,
+		WithLookuper(MapSource(map[string]string{
+			"APP_NAME":       "svc",
+			"APP_DB_HOST":    "db",
+			"APP_DB_PRIMARY": "truncated",
+			"TOKEN":          "alt",
+		})),
+		WithProvenance(&prov),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		key  string
+		kind OriginKind
+	}{
+		{"Name", "APP_NAME", FromKey},
+		{"Database.Host", "APP_DB_HOST", FromKey},
+		{"Database.Port", "", FromDefault},
+		{"Database.User", "APP_DB_PRIMARY", FromNested},
+		{"Token", "TOKEN", FromAlt},
+	}
+	for _, tt := range tests {
+		got, ok := prov[tt.path]
+		if !ok {
+			t.Errorf("Provenance missing %s", tt.path)
+			continue
+		}
+		if got.Key != tt.key || got.Kind != tt.kind {
+			t.Errorf("Provenance[%s] = %+v, want key %q kind %s", tt.path, got, tt.key, tt.kind)
+		}
+	}
+	if _, ok := prov["Missing"]; ok {
+		t.Errorf("Provenance should not contain unset fields")
+	}
+	if prov["Name"].Source != "map" {
+		t.Errorf("Expected source 'map', got %q", prov["Name"].Source)
+	}
+}
+
+func TestProvenanceFromLoader(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".env": "# comment\nTEST_STRING=file\nTEST_INT=3\n",
+	})
+
+	var prov Provenance
+	err := NewLoader("").
+		WithSource("env", MapSource(map[string]string{"TEST_INT": "4"})).
+		WithDotenv(DotenvCascade{Dir: dir}).
+		WithOptions(WithProvenance(&prov)).
+		Load(&TestConfig{})
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	str := prov["StringField"]
+	if str.Source != ".env" || str.File != filepath.Join(dir, ".env") || str.Line != 2 {
+		t.Errorf("Provenance[StringField] = %+v", str)
+	}
+	if prov["IntField"].Source != "env" {
+		t.Errorf("Provenance[IntField] = %+v", prov["IntField"])
+	}
+	if !strings.Contains(prov.String(), "StringField: TEST_STRING (key) from .env at ") {
+		t.Errorf("Provenance.String() = %q", prov.String())
+	}
+}
+
+func TestProvenanceNil(t *testing.T) {
+	config := &TestConfig{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{"TEST_STRING": "x"})),
+		WithProvenance(nil),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.StringField != "x" {
+		t.Errorf("Expected 'x', got %q", config.StringField)
+	}
+}
@@ -0,0 +1,187 @@
+package envx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefPrefix marks a value as a reference to be resolved, as in
+// "ref+file:///run/secrets/db_password".
+const RefPrefix = "ref+"
+
+var ErrUnknownScheme = errors.New("no resolver registered for scheme")
+
+// Resolver turns a reference into a value. ref is everything after
+// "ref+<scheme>://".
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// ExecResolver is not registered by default: any source able to supply a
+// value, such as a shared Consul KV store, could otherwise run commands.
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{
+		"file":  FileResolver{},
+		"vault": &VaultResolver{},
+	}
+)
+
+// RegisterResolver makes r available for scheme to every Process call that
+// enables references. It replaces any resolver registered for scheme.
+func RegisterResolver(scheme string, r Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[scheme] = r
+}
+
+// WithReferences makes Process resolve values starting with RefPrefix
+// using the registered resolvers.
+func WithReferences() Option {
+	return func(o *options) {
+		o.references = true
+	}
+}
+
+// WithResolver resolves scheme with r for this call only. It implies
+// WithReferences.
+func WithResolver(scheme string, r Resolver) Option {
+	return func(o *options) {
+		o.references = true
+		if o.resolvers == nil {
+			o.resolvers = make(map[string]Resolver)
+		}
+		o.resolvers[scheme] = r
+	}
+}
+
+// WithContext sets the context passed to resolvers.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+type refResolver struct {
+	ctx       context.Context
+	resolvers map[string]Resolver
+	cache     map[string]string
+}
+
+func newRefResolver(o *options) *refResolver {
+	r := &refResolver{
+		ctx:       o.ctx,
+		resolvers: make(map[string]Resolver),
+		cache:     make(map[string]string),
+	}
+	resolversMu.RLock()
+	for scheme, res := range resolvers {
+		r.resolvers[scheme] = res
+	}
+	resolversMu.RUnlock()
+	for scheme, res := range o.resolvers {
+		r.resolvers[scheme] = res
+	}
+	for scheme, res := range r.resolvers {
+		if sr, ok := res.(SessionResolver); ok {
+			r.resolvers[scheme] = sr.Session()
+		}
+	}
+	if r.ctx == nil {
+		r.ctx = context.Background()
+	}
+	return r
+}
+
+func (r *refResolver) resolve(value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, RefPrefix)
+	if !ok {
+		return value, nil
+	}
+	if v, ok := r.cache[rest]; ok {
+		return v, nil
+	}
+
+	scheme, ref, ok := strings.Cut(rest, "://")
+	if !ok {
+		return "", fmt.Errorf("malformed reference %q", value)
+	}
+	res, ok := r.resolvers[scheme]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownScheme, scheme)
+	}
+	v, err := res.Resolve(r.ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	r.cache[rest] = v
+	return v, nil
+}
+
+// FileResolver resolves ref+file:///path to the trimmed contents of path.
+type FileResolver struct {
+	// MaxPerm is the most permissive mode the file may have. Defaults to
+	// DefaultSecretFileMode.
+	MaxPerm fs.FileMode
+}
+
+func (f FileResolver) Resolve(_ context.Context, ref string) (string, error) {
+	perm := f.MaxPerm
+	if perm == 0 {
+		perm = DefaultSecretFileMode
+	}
+	return readSecretFile(ref, perm)
+}
+
+// DefaultExecTimeout bounds commands run by ExecResolver.
+const DefaultExecTimeout = 10 * time.Second
+
+// ExecResolver resolves ref+exec://command args... to the trimmed standard
+// output of the command. The command is split on white space and run
+// without a shell. Enable it with RegisterResolver("exec", ExecResolver{})
+// or WithResolver.
+type ExecResolver struct {
+	Timeout time.Duration
+}
+
+func (e ExecResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	args := strings.Fields(ref)
+	if len(args) == 0 {
+		return "", errors.New("empty exec reference")
+	}
+
+	timeout := e.Timeout
+	if timeout == 0 {
+		timeout = DefaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("running %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("running %s: %w", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
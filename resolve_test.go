@@ -0,0 +1,191 @@
+package envx
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveFileReference(t *testing.T) {
+	pw := writeSecret(t, t.TempDir(), "pw", "s3cret\n", 0o400)
+
+	config := &TestConfig{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{
+			"TEST_STRING": "ref+file://" + pw,
+			"TEST_INT":    "ref+file://" + pw,
+		})),
+		WithReferences(),
+	)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.KeyName != "TEST_INT" {
+		t.Fatalf("Expected ParseError converting secret to int, got %v", err)
+	}
+
+	config = &TestConfig{}
+	err = ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{"TEST_STRING": "ref+file://" + pw})),
+		WithReferences(),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.StringField != "s3cret" {
+		t.Errorf("Expected 's3cret', got %q", config.StringField)
+	}
+}
+
+func TestResolveDisabled(t *testing.T) {
+	config := &TestConfig{}
+	if err := ProcessMap("", config, map[string]string{"TEST_STRING": "ref+file:///etc/passwd"}); err != nil {
+		t.Fatalf("ProcessMap() unexpected error: %v", err)
+	}
+	if config.StringField != "ref+file:///etc/passwd" {
+		t.Errorf("Expected reference untouched without WithReferences, got %q", config.StringField)
+	}
+}
+
+func TestResolveCustomScheme(t *testing.T) {
+	calls := 0
+	res := ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		calls++
+		if ref == "fail" {
+			return "", errors.New("backend down")
+		}
+		return strings.ToUpper(ref), nil
+	})
+
+	type Config struct {
+		A    string   `envx:"A"`
+		B    string   `envx:"B"`
+		List []string `envx:"LIST"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{
+			"A":    "ref+custom://hello",
+			"B":    "ref+custom://hello",
+			"LIST": "ref+custom://x,y",
+		})),
+		WithResolver("custom", res),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.A != "HELLO" || config.B != "HELLO" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+	if len(config.List) != 2 || config.List[1] != "Y" {
+		t.Errorf("Expected resolved slice [X Y], got %v", config.List)
+	}
+	if calls != 2 {
+		t.Errorf("Expected identical references to be resolved once, got %d calls", calls)
+	}
+
+	err = ProcessWith("", &Config{},
+		WithLookuper(MapSource(map[string]string{"A": "ref+custom://fail"})),
+		WithResolver("custom", res),
+	)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.KeyName != "A" || !strings.Contains(err.Error(), "backend down") {
+		t.Errorf("Expected ParseError for A, got %v", err)
+	}
+}
+
+func TestRegisterResolver(t *testing.T) {
+	RegisterResolver("envxtest", ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		return "registered:" + ref, nil
+	}))
+
+	config := &TestConfig{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{"TEST_STRING": "ref+envxtest://x"})),
+		WithReferences(),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.StringField != "registered:x" {
+		t.Errorf("Expected 'registered:x', got %q", config.StringField)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr error
+	}{
+		{"unknown scheme", "ref+nope://x", ErrUnknownScheme},
+		{"exec not registered", "ref+exec://echo hi", ErrUnknownScheme},
+		{"malformed", "ref+file", nil},
+		{"missing file", "ref+file://" + filepath.Join(t.TempDir(), "missing"), ErrSecretFileMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProcessWith("", &TestConfig{},
+				WithLookuper(MapSource(map[string]string{"TEST_STRING": tt.value})),
+				WithReferences(),
+			)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Expected ParseError, got %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecResolver(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix commands")
+	}
+
+	ctx := context.Background()
+	got, err := ExecResolver{}.Resolve(ctx, "echo  hello   world")
+	if err != nil {
+		t.Fatalf("Resolve() unexpected error: %v", err)
+	}
+	if got != "hello world" {
+		t.Errorf("Resolve() = %q, want 'hello world'", got)
+	}
+
+	_, err = ExecResolver{}.Resolve(ctx, "ls /envx/does/not/exist")
+	if err == nil || !strings.Contains(err.Error(), "No such file") {
+		t.Errorf("Expected stderr in error, got %v", err)
+	}
+
+	_, err = ExecResolver{Timeout: 50 * time.Millisecond}.Resolve(ctx, "sleep 5")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+
+	if _, err := (ExecResolver{}).Resolve(ctx, "  "); err == nil {
+		t.Errorf("Expected error for empty command")
+	}
+}
+
+func TestExecResolverOptIn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix commands")
+	}
+
+	config := &TestConfig{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(map[string]string{"TEST_STRING": "ref+exec://echo hi"})),
+		WithResolver("exec", ExecResolver{}),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.StringField != "hi" {
+		t.Errorf("Expected 'hi', got %q", config.StringField)
+	}
+}
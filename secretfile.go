@@ -0,0 +1,87 @@
+package envx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+var (
+	ErrSecretFileMissing     = errors.New("secret file does not exist")
+	ErrSecretFileUnreadable  = errors.New("secret file is not readable")
+	ErrSecretFilePermissions = errors.New("secret file permissions are too open")
+)
+
+// DefaultSecretFileMode is the most permissive mode a secret file may have
+// unless changed with WithSecretFileMode.
+const DefaultSecretFileMode fs.FileMode = 0o644
+
+type SecretFileError struct {
+	KeyName   string
+	FieldName string
+	Path      string
+	Err       error
+}
+
+func (e *SecretFileError) Error() string {
+	return fmt.Sprintf("envx.Process: assigning %[1]s to %[2]s: reading secret file '%[3]s': %[4]s", e.KeyName, e.FieldName, e.Path, e.Err)
+}
+
+func (e *SecretFileError) Unwrap() error {
+	return e.Err
+}
+
+// WithSecretFiles makes Process fall back to <KEY>_FILE when KEY is not
+// set, reading the value from the file it names. This is the convention
+// used by Docker and Kubernetes secrets.
+func WithSecretFiles() Option {
+	return func(o *options) {
+		o.secretFiles = true
+	}
+}
+
+// WithSecretFileMode sets the most permissive mode a secret file may have.
+// Files granting any permission bit outside perm are rejected.
+func WithSecretFileMode(perm fs.FileMode) Option {
+	return func(o *options) {
+		o.secretFileMode = perm
+	}
+}
+
+func lookupFileKey(l Lookuper, info varInfo) (string, Origin, bool) {
+	key := info.Key + "_FILE"
+	if value, ok := l.Lookup(key); ok {
+		return value, Origin{Key: key, Kind: FromKey}, true
+	}
+	if info.Alt != "" {
+		key = info.Alt + "_FILE"
+		if value, ok := l.Lookup(key); ok {
+			return value, Origin{Key: key, Kind: FromAlt}, true
+		}
+	}
+	return "", Origin{}, false
+}
+
+func readSecretFile(path string, maxPerm fs.FileMode) (string, error) {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrSecretFileMissing
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSecretFileUnreadable, err)
+	}
+	if fi.IsDir() {
+		return "", fmt.Errorf("%w: is a directory", ErrSecretFileUnreadable)
+	}
+	if err := checkFileMode(fi.Mode(), maxPerm); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSecretFileUnreadable, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
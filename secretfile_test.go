@@ -0,0 +1,158 @@
+package envx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func writeSecret(t *testing.T, dir, name, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSecretFileSuffix(t *testing.T) {
+	dir := t.TempDir()
+	pw := writeSecret(t, dir, "db_password", "  s3cret\n", 0o400)
+	port := writeSecret(t, dir, "db_port", "5432\n", 0o444)
+
+	type Config struct {
+		Password string `envx:"DB_PASSWORD"`
+		Port     int    `envx:"DB_PORT"`
+		User     string `envx:"DB_USER"`
+	}
+
+	var prov Provenance
+	config := &Config{}
+	err := ProcessWith("APP", config,
+		WithLookuper(MapSource(map[string]string{
+			"APP_DB_PASSWORD_FILE": pw,
+			"DB_PORT_FILE":         port,
+			"APP_DB_USER":          "direct",
+			"APP_DB_USER_FILE":     "/does/not/matter",
+		})),
+		WithSecretFiles(),
+		WithProvenance(&prov),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if config.Password != "s3cret" {
+		t.Errorf("Expected Password 's3cret', got %q", config.Password)
+	}
+	if config.Port != 5432 {
+		t.Errorf("Expected Port 5432, got %d", config.Port)
+	}
+	if config.User != "direct" {
+		t.Errorf("Expected direct value to win over _FILE, got %q", config.User)
+	}
+	if got := prov["Password"]; got.Key != "APP_DB_PASSWORD_FILE" || got.SecretFile != pw {
+		t.Errorf("Provenance[Password] = %+v", got)
+	}
+}
+
+func TestSecretFileSuffixDisabled(t *testing.T) {
+	dir := t.TempDir()
+	pw := writeSecret(t, dir, "pw", "s3cret", 0o400)
+
+	config := &TestConfig{}
+	err := ProcessMap("", config, map[string]string{"TEST_STRING_FILE": pw})
+	if err != nil {
+		t.Fatalf("ProcessMap() unexpected error: %v", err)
+	}
+	if config.StringField != "" {
+		t.Errorf("Expected _FILE to be ignored without WithSecretFiles, got %q", config.StringField)
+	}
+}
+
+func TestSecretFileTag(t *testing.T) {
+	dir := t.TempDir()
+	key := writeSecret(t, dir, "key.pem", "-----BEGIN-----\nabc\n-----END-----\n", 0o600)
+
+	type Config struct {
+		Key string `envx:"TLS_KEY" file:"true"`
+	}
+
+	config := &Config{}
+	err := ProcessMap("", config, map[string]string{"TLS_KEY": key})
+	if err != nil {
+		t.Fatalf("ProcessMap() unexpected error: %v", err)
+	}
+	if config.Key != "-----BEGIN-----\nabc\n-----END-----" {
+		t.Errorf("Expected trimmed file contents, got %q", config.Key)
+	}
+
+}
+
+func TestSecretFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	open := writeSecret(t, dir, "open", "x", 0o666)
+	exec := writeSecret(t, dir, "exec", "x", 0o755)
+	ok := writeSecret(t, dir, "ok", "x", 0o600)
+
+	type Config struct {
+		Secret string `envx:"SECRET" file:"true"`
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		opts    []Option
+		wantErr error
+		unix    bool
+	}{
+		{name: "missing", path: filepath.Join(dir, "nope"), wantErr: ErrSecretFileMissing},
+		{name: "directory", path: dir, wantErr: ErrSecretFileUnreadable},
+		{name: "world writable", path: open, wantErr: ErrSecretFilePermissions, unix: true},
+		{name: "executable", path: exec, wantErr: ErrSecretFilePermissions, unix: true},
+		{name: "relaxed mode", path: exec, opts: []Option{WithSecretFileMode(0o755)}},
+		{name: "strict mode", path: ok, opts: []Option{WithSecretFileMode(0o400)}, wantErr: ErrSecretFilePermissions, unix: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.unix && runtime.GOOS == "windows" {
+				t.Skip("file modes are not checked on Windows")
+			}
+			opts := append([]Option{WithLookuper(MapSource(map[string]string{"SECRET": tt.path}))}, tt.opts...)
+			err := ProcessWith("", &Config{}, opts...)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ProcessWith() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProcessWith() expected %v, got %v", tt.wantErr, err)
+			}
+			var serr *SecretFileError
+			if !errors.As(err, &serr) || serr.Path != tt.path {
+				t.Errorf("Expected *SecretFileError for %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestCheckDisallowedSecretFiles(t *testing.T) {
+	type Config struct {
+		Password string `envx:"PASSWORD"`
+	}
+	src := MapSource(map[string]string{"APP_PASSWORD_FILE": "/x", "PASSWORD_FILE": "/y"})
+
+	if err := CheckDisallowed("APP", &Config{}, WithLookuper(src), WithSecretFiles()); err != nil {
+		t.Errorf("CheckDisallowed() unexpected error: %v", err)
+	}
+	if err := CheckDisallowed("APP", &Config{}, WithLookuper(src)); err == nil {
+		t.Errorf("CheckDisallowed() expected APP_PASSWORD_FILE to be unknown without WithSecretFiles")
+	}
+}
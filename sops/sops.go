@@ -0,0 +1,289 @@
+// Package sops reads dotenv files encrypted with SOPS and age as an envx
+// source, without the sops binary. It lives in its own package so that
+// programs using only envx do not build age and golang.org/x/crypto.
+package sops
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/justblue0312/envx"
+)
+
+var (
+	ErrMAC        = errors.New("sops: MAC mismatch, file was tampered with or corrupted")
+	ErrNoIdentity = errors.New("sops: no age identity can decrypt the data key")
+	ErrMetadata   = errors.New("sops: missing or invalid metadata")
+)
+
+var sopsValueRegexp = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+type sopsEntry struct {
+	key   string
+	value string
+	line  int
+}
+
+type sopsSource struct {
+	file   string
+	values map[string]string
+	lines  map[string]int
+}
+
+// Source decrypts a SOPS encrypted dotenv file with the age identities
+// found in $SOPS_AGE_KEY, $SOPS_AGE_KEY_FILE or the default SOPS key file,
+// verifies its MAC and returns the values as an envx.Lookuper.
+func Source(path string) (envx.Lookuper, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := sopsAgeIdentities()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := decryptSops(f, identities)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s := &sopsSource{
+		file:   path,
+		values: make(map[string]string, len(entries)),
+		lines:  make(map[string]int, len(entries)),
+	}
+	for _, e := range entries {
+		s.values[e.key] = e.value
+		s.lines[e.key] = e.line
+	}
+	return s, nil
+}
+
+// Decrypt decrypts a SOPS encrypted dotenv document from r using the
+// given age identities and verifies its MAC.
+func Decrypt(r io.Reader, identities ...age.Identity) (map[string]string, error) {
+	entries, err := decryptSops(r, identities)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(entries))
+	for _, e := range entries {
+		m[e.key] = e.value
+	}
+	return m, nil
+}
+
+func sopsAgeIdentities() ([]age.Identity, error) {
+	var identities []age.Identity
+	if key, ok := os.LookupEnv("SOPS_AGE_KEY"); ok && key != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("sops: parsing SOPS_AGE_KEY: %w", err)
+		}
+		identities = append(identities, ids...)
+	}
+
+	path, _ := os.LookupEnv("SOPS_AGE_KEY_FILE")
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "sops", "age", "keys.txt")
+			if _, err := os.Stat(path); err != nil {
+				path = ""
+			}
+		}
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		ids, err := age.ParseIdentities(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("sops: parsing %s: %w", path, err)
+		}
+		identities = append(identities, ids...)
+	}
+
+	if len(identities) == 0 {
+		return nil, ErrNoIdentity
+	}
+	return identities, nil
+}
+
+func decryptSops(r io.Reader, identities []age.Identity) ([]sopsEntry, error) {
+	entries, meta, err := parseSopsDotenv(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := sopsDataKey(meta, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	macOnlyEncrypted, _ := strconv.ParseBool(meta["sops_mac_only_encrypted"])
+	hash := sha512.New()
+	for i, e := range entries {
+		m := sopsValueRegexp.FindStringSubmatch(e.value)
+		if m == nil {
+			if !macOnlyEncrypted {
+				hash.Write([]byte(e.value))
+			}
+			continue
+		}
+		plain, err := sopsDecrypt(m, dataKey, e.key+":")
+		if err != nil {
+			return nil, &envx.DotenvError{Line: e.line, Err: fmt.Errorf("sops: decrypting %s: %w", e.key, err)}
+		}
+		hash.Write([]byte(plain))
+		entries[i].value = plain
+	}
+
+	lastModified, err := time.Parse(time.RFC3339, meta["sops_lastmodified"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: sops_lastmodified: %w", ErrMetadata, err)
+	}
+	m := sopsValueRegexp.FindStringSubmatch(meta["sops_mac"])
+	if m == nil {
+		return nil, fmt.Errorf("%w: sops_mac", ErrMetadata)
+	}
+	mac, err := sopsDecrypt(m, dataKey, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMAC, err)
+	}
+	if mac != fmt.Sprintf("%X", hash.Sum(nil)) {
+		return nil, ErrMAC
+	}
+
+	return entries, nil
+}
+
+// parseSopsDotenv splits a SOPS dotenv document into values and sops_*
+// metadata. SOPS writes one KEY=VALUE per line with newlines escaped as
+// "\n" and performs no quoting or expansion.
+func parseSopsDotenv(r io.Reader) ([]sopsEntry, map[string]string, error) {
+	var entries []sopsEntry
+	meta := make(map[string]string)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, nil, &envx.DotenvError{Line: line, Err: fmt.Errorf("expected '=' in %q", text)}
+		}
+		value = strings.ReplaceAll(value, `\n`, "\n")
+		if strings.HasPrefix(key, "sops_") {
+			meta[key] = value
+			continue
+		}
+		entries = append(entries, sopsEntry{key: key, value: value, line: line})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	if meta["sops_mac"] == "" {
+		return nil, nil, fmt.Errorf("%w: not a SOPS file", ErrMetadata)
+	}
+	return entries, meta, nil
+}
+
+func sopsDataKey(meta map[string]string, identities []age.Identity) ([]byte, error) {
+	var stanzas []string
+	for k := range meta {
+		if strings.HasPrefix(k, "sops_age__list_") && strings.HasSuffix(k, "__map_enc") {
+			stanzas = append(stanzas, k)
+		}
+	}
+	if len(stanzas) == 0 {
+		return nil, fmt.Errorf("%w: no age recipients", ErrMetadata)
+	}
+	sort.Strings(stanzas)
+
+	for _, k := range stanzas {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(meta[k])), identities...)
+		if err != nil {
+			continue
+		}
+		key, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	return nil, ErrNoIdentity
+}
+
+func sopsDecrypt(m []string, key []byte, additionalData string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(m[1])
+	if err != nil {
+		return "", err
+	}
+	iv, err := base64.StdEncoding.DecodeString(m[2])
+	if err != nil {
+		return "", err
+	}
+	tag, err := base64.StdEncoding.DecodeString(m[3])
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", err
+	}
+	plain, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (s *sopsSource) Lookup(key string) (string, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s *sopsSource) Keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Locate reports the file and line a key was read from in envx.Provenance.
+func (s *sopsSource) Locate(o envx.Origin) envx.Origin {
+	o.Source = s.file
+	o.File = s.file
+	o.Line = s.lines[o.Key]
+	return o
+}
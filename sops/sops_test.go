@@ -0,0 +1,222 @@
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/justblue0312/envx"
+)
+
+func sopsEncrypt(t *testing.T, key []byte, plaintext, additionalData string) string {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(out[:len(out)-16]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(out[len(out)-16:]))
+}
+
+// writeSopsFile produces a dotenv file in the layout written by
+// `sops --encrypt --age <recipient> .env`.
+func writeSopsFile(t *testing.T, recipient age.Recipient, values [][2]string, tamper bool) string {
+	t.Helper()
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	hash := sha512.New()
+	for _, kv := range values {
+		key, value := kv[0], kv[1]
+		hash.Write([]byte(value))
+		if strings.HasSuffix(key, "_unencrypted") {
+			lines = append(lines, key+"="+value)
+			continue
+		}
+		lines = append(lines, key+"="+sopsEncrypt(t, dataKey, value, key+":"))
+	}
+	if tamper {
+		hash.Write([]byte("x"))
+	}
+
+	var armored bytes.Buffer
+	aw := armor.NewWriter(&armored)
+	w, err := age.Encrypt(aw, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(dataKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lastModified := "2024-05-01T10:00:00Z"
+	mac := sopsEncrypt(t, dataKey, fmt.Sprintf("%X", hash.Sum(nil)), lastModified)
+	lines = append(lines,
+		"sops_age__list_0__map_enc="+strings.ReplaceAll(armored.String(), "\n", `\n`),
+		"sops_age__list_0__map_recipient="+recipient.(*age.X25519Recipient).String(),
+		"sops_lastmodified="+lastModified,
+		"sops_mac="+mac,
+		"sops_unencrypted_suffix=_unencrypted",
+		"sops_version=3.8.1",
+	)
+
+	path := filepath.Join(t.TempDir(), ".env.enc")
+	if err := os.WriteFile(path, []byte("# managed by sops\n"+strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newAgeIdentity(t *testing.T) *age.X25519Identity {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestSource(t *testing.T) {
+	id := newAgeIdentity(t)
+	path := writeSopsFile(t, id.Recipient(), [][2]string{
+		{"DB_PASSWORD", "s3cret"},
+		{"DB_PORT", "5432"},
+		{"CERT", "line1\nline2"},
+		{"EMPTY", ""},
+		{"REGION_unencrypted", "eu-west-1"},
+	}, false)
+
+	keyFile := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(keyFile, []byte("# created: now\n"+id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOPS_AGE_KEY", "")
+	t.Setenv("SOPS_AGE_KEY_FILE", keyFile)
+
+	src, err := Source(path)
+	if err != nil {
+		t.Fatalf("Source() unexpected error: %v", err)
+	}
+
+	type Config struct {
+		Password string `envx:"DB_PASSWORD"`
+		Port     int    `envx:"DB_PORT"`
+		Cert     string `envx:"CERT"`
+	}
+	var prov envx.Provenance
+	config := &Config{}
+	if err := envx.ProcessWith("", config, envx.WithLookuper(src), envx.WithProvenance(&prov)); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Password != "s3cret" || config.Port != 5432 || config.Cert != "line1\nline2" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+	if v, _ := src.Lookup("REGION_unencrypted"); v != "eu-west-1" {
+		t.Errorf("Expected unencrypted value, got %q", v)
+	}
+	if got := prov["Port"]; got.File != path || got.Line != 3 {
+		t.Errorf("Provenance[Port] = %+v", got)
+	}
+	if _, ok := src.Lookup("sops_mac"); ok {
+		t.Errorf("Metadata should not be exposed as values")
+	}
+}
+
+func TestSourceInlineKey(t *testing.T) {
+	id := newAgeIdentity(t)
+	path := writeSopsFile(t, id.Recipient(), [][2]string{{"TOKEN", "abc"}}, false)
+
+	t.Setenv("SOPS_AGE_KEY", id.String())
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+	src, err := Source(path)
+	if err != nil {
+		t.Fatalf("Source() unexpected error: %v", err)
+	}
+	if v, _ := src.Lookup("TOKEN"); v != "abc" {
+		t.Errorf("Lookup(TOKEN) = %q", v)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	id := newAgeIdentity(t)
+	other := newAgeIdentity(t)
+
+	tampered := writeSopsFile(t, id.Recipient(), [][2]string{{"A", "1"}}, true)
+	valid := writeSopsFile(t, id.Recipient(), [][2]string{{"A", "1"}}, false)
+
+	tests := []struct {
+		name     string
+		path     string
+		identity age.Identity
+		edit     func(string) string
+		want     error
+	}{
+		{name: "mac mismatch", path: tampered, identity: id, want: ErrMAC},
+		{name: "wrong identity", path: valid, identity: other, want: ErrNoIdentity},
+		{
+			name:     "value swapped",
+			path:     valid,
+			identity: id,
+			edit:     func(s string) string { return strings.Replace(s, "A=ENC", "B=ENC", 1) },
+		},
+		{
+			name:     "not sops",
+			path:     valid,
+			identity: id,
+			edit:     func(string) string { return "A=1\n" },
+			want:     ErrMetadata,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := string(data)
+			if tt.edit != nil {
+				content = tt.edit(content)
+			}
+			_, err = Decrypt(strings.NewReader(content), tt.identity)
+			if err == nil {
+				t.Fatalf("Decrypt() expected error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt() expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package envx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidTag = errors.New("invalid struct tag")
+
+// fieldTags holds the envx options of a struct field, from either the
+// compact `envx:"NAME,opt,key=value"` form or the separate tags.
+type fieldTags struct {
+	Name       string
+	Default    string
+	Required   bool
+	Ignored    bool
+	SplitWords bool
+	File       bool
+	Squash     bool
+	NoPrefix   bool
+	// Nested is "true", "false" or empty when not set.
+	Nested     string
+	Prefix     string
+	Desc       string
+	Credential string
+	// Aliases are full keys; the prefix is not added to them.
+	Aliases []string
+	// Sep separates slice elements and map pairs. Defaults to ",".
+	Sep string
+	// KVSep separates the key and value of a map pair. Defaults to ":".
+	KVSep string
+	// NotEmpty, Expand and Unset are only set by DialectEnv.
+	NotEmpty bool
+	Expand   bool
+	Unset    bool
+	// Unknown lists separate tags that are neither options nor foreignTags.
+	Unknown []string
+}
+
+// tagOptions lists the options that can be given either as separate tags
+// or inside the envx tag. The aliases tag has its own comma-separated list
+// and is only read as a separate tag.
+var tagOptions = map[string]func(t *fieldTags, v string) error{
+	"default":     func(t *fieldTags, v string) error { t.Default = v; return nil },
+	"required":    boolOption(func(t *fieldTags) *bool { return &t.Required }),
+	"ignored":     boolOption(func(t *fieldTags) *bool { return &t.Ignored }),
+	"split_words": boolOption(func(t *fieldTags) *bool { return &t.SplitWords }),
+	"file":        boolOption(func(t *fieldTags) *bool { return &t.File }),
+	"squash":      boolOption(func(t *fieldTags) *bool { return &t.Squash }),
+	"noprefix":    boolOption(func(t *fieldTags) *bool { return &t.NoPrefix }),
+	"nested": func(t *fieldTags, v string) error {
+		t.Nested = strconv.FormatBool(isTrue(v))
+		return nil
+	},
+	"prefix":     func(t *fieldTags, v string) error { t.Prefix = v; return nil },
+	"desc":       func(t *fieldTags, v string) error { t.Desc = v; return nil },
+	"credential": func(t *fieldTags, v string) error { t.Credential = v; return nil },
+	"sep": func(t *fieldTags, v string) error {
+		if v == "" {
+			return errors.New("empty separator")
+		}
+		t.Sep = v
+		return nil
+	},
+}
+
+// boolOption parses the value of a boolean option. Separate tags have
+// always accepted anything strconv.ParseBool does not understand as false,
+// so only the compact form is strict about it.
+func boolOption(field func(t *fieldTags) *bool) func(t *fieldTags, v string) error {
+	return func(t *fieldTags, v string) error {
+		*field(t) = isTrue(v)
+		return nil
+	}
+}
+
+// foreignTags are tags of other packages that commonly sit next to envx
+// tags and must never be mistaken for misspelled envx options.
+var foreignTags = map[string]bool{
+	"json": true, "yaml": true, "toml": true, "xml": true, "hcl": true,
+	"mapstructure": true, "validate": true, "flag": true, "db": true,
+	"bson": true, "form": true, "gorm": true, "protobuf": true,
+	"env": true, "envDefault": true, "envSeparator": true, "envconfig": true,
+	"envPrefix": true, "envKeyValSeparator": true,
+}
+
+// parseTags reads the envx options of a struct field. Options may be given
+// both ways as long as they do not conflict. Separate tags that look like a
+// misspelled option, such as `requried`, are reported as errors; other
+// unknown tags are recorded in Unknown for Strict to reject.
+func parseTags(tag reflect.StructTag, d Dialect) (fieldTags, error) {
+	if d == DialectEnv {
+		return parseEnvTags(tag)
+	}
+
+	t := fieldTags{Sep: ",", KVSep: ":"}
+	set := make(map[string]string)
+
+	if d == DialectEnvconfig {
+		t.Name = tag.Get("envconfig")
+	} else if err := parseCompact(&t, tag.Get("envx"), set); err != nil {
+		return t, err
+	}
+
+	for _, key := range tagKeys(tag) {
+		if key == "envx" || key == "envconfig" || foreignTags[key] {
+			continue
+		}
+		value := tag.Get(key)
+		if key == "aliases" {
+			for _, alias := range strings.Split(value, ",") {
+				if alias = strings.TrimSpace(alias); alias != "" {
+					t.Aliases = append(t.Aliases, alias)
+				}
+			}
+			continue
+		}
+		parse, known := tagOptions[key]
+		if !known {
+			if guess := closestOption(key); guess != "" {
+				return t, fmt.Errorf("%w: unknown tag %q, did you mean %q?", ErrInvalidTag, key, guess)
+			}
+			t.Unknown = append(t.Unknown, key)
+			continue
+		}
+		if prev, dup := set[key]; dup && prev != value {
+			return t, fmt.Errorf("%w: %s is set both in the envx tag and as a separate tag", ErrInvalidTag, key)
+		}
+		if err := parse(&t, value); err != nil {
+			return t, fmt.Errorf("%w: tag %q: %v", ErrInvalidTag, key, err)
+		}
+	}
+	return t, nil
+}
+
+// parseCompact parses the `envx:"NAME,opt,key=value"` form into t and
+// records every option it sets in set.
+func parseCompact(t *fieldTags, compact string, set map[string]string) error {
+	name, opts := splitTagOptions(compact)
+	t.Name = name
+	for _, opt := range opts {
+		key, value, hasValue := strings.Cut(opt, "=")
+		key = strings.TrimSpace(key)
+		parse, known := tagOptions[key]
+		if !known {
+			return fmt.Errorf("%w: unknown option %q in envx tag", ErrInvalidTag, key)
+		}
+		if !hasValue {
+			if _, isBool := boolOptions[key]; !isBool {
+				return fmt.Errorf("%w: option %q in envx tag needs a value", ErrInvalidTag, key)
+			}
+			value = "true"
+		} else if _, isBool := boolOptions[key]; isBool {
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("%w: option %q in envx tag: %v", ErrInvalidTag, key, err)
+			}
+		}
+		if err := parse(t, value); err != nil {
+			return fmt.Errorf("%w: option %q in envx tag: %v", ErrInvalidTag, key, err)
+		}
+		set[key] = value
+	}
+	return nil
+}
+
+var boolOptions = map[string]struct{}{
+	"required": {}, "ignored": {}, "split_words": {}, "file": {},
+	"squash": {}, "noprefix": {}, "nested": {},
+}
+
+// splitTagOptions splits an envx tag into the key name and its options.
+// A comma inside an option value is written as `\,`.
+func splitTagOptions(tag string) (string, []string) {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(tag[i])
+		}
+	}
+	parts = append(parts, b.String())
+
+	var opts []string
+	for _, p := range parts[1:] {
+		if strings.TrimSpace(p) != "" {
+			opts = append(opts, p)
+		}
+	}
+	return strings.TrimSpace(parts[0]), opts
+}
+
+// tagKeys returns the keys of tag in order, following the conventional
+// format parsed by reflect.StructTag.
+func tagKeys(tag reflect.StructTag) []string {
+	var keys []string
+	s := string(tag)
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		s = s[i:]
+		if s == "" {
+			break
+		}
+
+		i = 0
+		for i < len(s) && s[i] > ' ' && s[i] != ':' && s[i] != '"' && s[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(s) || s[i] != ':' || s[i+1] != '"' {
+			break
+		}
+		keys = append(keys, s[:i])
+		s = s[i+1:]
+
+		i = 1
+		for i < len(s) && s[i] != '"' {
+			if s[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		s = s[i+1:]
+	}
+	return keys
+}
+
+// closestOption returns the option name key is most likely a misspelling
+// of, or "".
+func closestOption(key string) string {
+	names := make([]string, 0, len(tagOptions)+1)
+	for name := range tagOptions {
+		names = append(names, name)
+	}
+	names = append(names, "aliases", "envx")
+
+	best, bestDist := "", 0
+	for _, name := range names {
+		max := 1
+		if len(name) >= 6 {
+			max = 2
+		}
+		d := editDistance(strings.ToLower(key), name)
+		if d <= max && (best == "" || d < bestDist || d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and transpositions of adjacent letters needed to turn a into b.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// unknownTag is a tag Strict rejects, on the field at Path.
+type unknownTag struct {
+	Path string
+	Tag  string
+}
+
+// checkUnknownTags fails on the first unknown tag of infos that is not in
+// allowed.
+func checkUnknownTags(infos []varInfo, allowed map[string]bool) error {
+	for _, info := range infos {
+		for _, u := range info.Unknown {
+			if !allowed[u.Tag] {
+				return fmt.Errorf("envx: field %s: %w: unknown tag %q, see WithAllowedTags", u.Path, ErrInvalidTag, u.Tag)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package envx
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		tag     reflect.StructTag
+		want    fieldTags
+		wantErr string
+	}{
+		{
+			tag:  `envx:"DB_HOST,required,default=localhost,sep=;"`,
+			want: fieldTags{Name: "DB_HOST", Required: true, Default: "localhost", Sep: ";", KVSep: ":"},
+		},
+		{
+			tag:  `envx:"DB_HOST" default:"localhost" required:"true" split_words:"true"`,
+			want: fieldTags{Name: "DB_HOST", Required: true, Default: "localhost", SplitWords: true, Sep: ",", KVSep: ":"},
+		},
+		{
+			tag:  `envx:",split_words,file,desc=Primary database"`,
+			want: fieldTags{SplitWords: true, File: true, Desc: "Primary database", Sep: ",", KVSep: ":"},
+		},
+		{
+			tag:  `envx:"HOSTS,default=a\\,b"`,
+			want: fieldTags{Name: "HOSTS", Default: "a,b", Sep: ",", KVSep: ":"},
+		},
+		{
+			tag:  `envx:"DB,prefix=PG,nested=true,required=false" json:"db" aliases:"OLD_DB, LEGACY_DB"`,
+			want: fieldTags{Name: "DB", Prefix: "PG", Nested: "true", Aliases: []string{"OLD_DB", "LEGACY_DB"}, Sep: ",", KVSep: ":"},
+		},
+		{
+			tag:  `envx:"HOST,required" required:"true"`,
+			want: fieldTags{Name: "HOST", Required: true, Sep: ",", KVSep: ":"},
+		},
+		{
+			tag:  `envx:"HOST" yaml:"host" mapstructure:"host"`,
+			want: fieldTags{Name: "HOST", Sep: ",", KVSep: ":"},
+		},
+		{
+			tag:  `envx:"HOST" custom:"x"`,
+			want: fieldTags{Name: "HOST", Sep: ",", KVSep: ":", Unknown: []string{"custom"}},
+		},
+		{tag: `envx:"HOST,requird"`, wantErr: `unknown option "requird"`},
+		{tag: `envx:"HOST,default"`, wantErr: `needs a value`},
+		{tag: `envx:"HOST,required=maybe"`, wantErr: `option "required"`},
+		{tag: `envx:"HOST,sep="`, wantErr: `empty separator`},
+		{tag: `envx:"HOST" requried:"true"`, wantErr: `did you mean "required"`},
+		{tag: `envx:"HOST" defualt:"x"`, wantErr: `did you mean "default"`},
+		{tag: `envx:"HOST,default=a" default:"b"`, wantErr: `set both`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.tag), func(t *testing.T) {
+			got, err := parseTags(tt.tag, DialectEnvx)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidTag) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseTags() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTags() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTags() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessStrictUnknownTags(t *testing.T) {
+	type Inner struct {
+		Host string `envx:"HOST" mytag:"x"`
+	}
+	type Config struct {
+		Name string `envx:"NAME" protobuf:"bytes,1" protobuf_oneof:"x"`
+		DB   Inner  `envx:"DB" cbor:"db"`
+	}
+
+	src := WithLookuper(MapSource(map[string]string{"NAME": "svc", "DB_HOST": "x"}))
+	config := &Config{}
+	if err := ProcessWith("", config, src); err != nil {
+		t.Fatalf("ProcessWith() unexpected error without Strict: %v", err)
+	}
+	if config.Name != "svc" || config.DB.Host != "x" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		wantErr string
+	}{
+		{name: "none allowed", wantErr: `field Name: invalid struct tag: unknown tag "protobuf_oneof"`},
+		{name: "enclosing struct", allowed: []string{"protobuf_oneof"}, wantErr: `field DB: invalid struct tag: unknown tag "cbor"`},
+		{name: "nested field", allowed: []string{"protobuf_oneof", "cbor"}, wantErr: `field DB.Host: invalid struct tag: unknown tag "mytag"`},
+		{name: "all allowed", allowed: []string{"protobuf_oneof", "cbor", "mytag"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProcessWith("", &Config{}, src, Strict(), WithAllowedTags(tt.allowed...))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ProcessWith() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidTag) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ProcessWith() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProcessCompactTags(t *testing.T) {
+	type Config struct {
+		Host    string            `envx:"DB_HOST,required,default=localhost"`
+		Hosts   []string          `envx:"HOSTS,sep=;"`
+		Labels  map[string]string `envx:"LABELS,sep=;"`
+		Ignored string            `envx:"IGNORED,ignored"`
+		Name    string            `envx:",required"`
+	}
+
+	config := &Config{}
+	err := ProcessWith("APP", config, WithLookuper(MapSource(map[string]string{
+		"APP_HOSTS":   "a,1;b,2",
+		"APP_LABELS":  "team:core;tier:1",
+		"APP_IGNORED": "x",
+		"APP_NAME":    "svc",
+	})))
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+
+	if config.Host != "localhost" {
+		t.Errorf("Expected Host 'localhost', got %q", config.Host)
+	}
+	if len(config.Hosts) != 2 || config.Hosts[1] != "b,2" {
+		t.Errorf("Expected Hosts [a,1 b,2], got %q", config.Hosts)
+	}
+	if config.Labels["tier"] != "1" {
+		t.Errorf("Expected Labels[tier] '1', got %v", config.Labels)
+	}
+	if config.Ignored != "" {
+		t.Errorf("Expected Ignored to be skipped, got %q", config.Ignored)
+	}
+	if config.Name != "svc" {
+		t.Errorf("Expected Name 'svc', got %q", config.Name)
+	}
+
+	err = ProcessWith("APP", &Config{}, WithLookuper(MapSource(nil)))
+	if err == nil || !strings.Contains(err.Error(), "APP_NAME") {
+		t.Errorf("ProcessWith() expected missing APP_NAME error, got %v", err)
+	}
+}
+
+func TestProcessMisspelledTag(t *testing.T) {
+	type Config struct {
+		Host string `envx:"HOST" requried:"true"`
+	}
+	err := ProcessWith("", &Config{}, WithLookuper(MapSource(nil)))
+	if !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("ProcessWith() expected ErrInvalidTag, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package envx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SessionResolver is implemented by resolvers that keep state, such as a
+// response cache, for the duration of one Process call. Session is called
+// once per call and the returned Resolver is used in place of the original.
+type SessionResolver interface {
+	Resolver
+	Session() Resolver
+}
+
+var ErrVaultNotConfigured = errors.New("vault address or credentials not set")
+
+type VaultError struct {
+	Path       string
+	StatusCode int
+	Errors     []string
+}
+
+func (e *VaultError) Error() string {
+	msg := fmt.Sprintf("vault: %s: %d %s", e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Errors) > 0 {
+		msg += ": " + strings.Join(e.Errors, "; ")
+	}
+	return msg
+}
+
+// VaultResolver resolves ref+vault://<path>#<key> against the HashiCorp
+// Vault KV v2 HTTP API, e.g. ref+vault://secret/data/app#password.
+// Each path is fetched at most once per Process call.
+type VaultResolver struct {
+	// Addr defaults to $VAULT_ADDR.
+	Addr string
+	// Token defaults to $VAULT_TOKEN. When empty, an AppRole login is
+	// performed with RoleID and SecretID.
+	Token string
+	// RoleID and SecretID default to $VAULT_ROLE_ID and $VAULT_SECRET_ID.
+	RoleID   string
+	SecretID string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (v *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	return v.Session().Resolve(ctx, ref)
+}
+
+func (v *VaultResolver) Session() Resolver {
+	s := &vaultSession{
+		addr:     v.Addr,
+		token:    v.Token,
+		roleID:   v.RoleID,
+		secretID: v.SecretID,
+		client:   v.Client,
+		paths:    make(map[string]map[string]any),
+	}
+	if s.addr == "" {
+		s.addr, _ = lookupEnv("VAULT_ADDR")
+	}
+	if s.token == "" {
+		s.token, _ = lookupEnv("VAULT_TOKEN")
+	}
+	if s.roleID == "" {
+		s.roleID, _ = lookupEnv("VAULT_ROLE_ID")
+	}
+	if s.secretID == "" {
+		s.secretID, _ = lookupEnv("VAULT_SECRET_ID")
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	s.addr = strings.TrimRight(s.addr, "/")
+	return s
+}
+
+type vaultSession struct {
+	addr     string
+	token    string
+	roleID   string
+	secretID string
+	client   *http.Client
+	paths    map[string]map[string]any
+}
+
+func (s *vaultSession) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault reference %q must be <path>#<key>", ref)
+	}
+	path = strings.Trim(path, "/")
+
+	data, ok := s.paths[path]
+	if !ok {
+		var err error
+		data, err = s.read(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		s.paths[path] = data
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no key %q", path, key)
+	}
+	if str, ok := scalarString(value); ok {
+		return str, nil
+	}
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+func (s *vaultSession) read(ctx context.Context, path string) (map[string]any, error) {
+	if s.addr == "" {
+		return nil, ErrVaultNotConfigured
+	}
+	if s.token == "" {
+		if err := s.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := s.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("vault: %s: response has no KV v2 data", path)
+	}
+	return resp.Data.Data, nil
+}
+
+func (s *vaultSession) login(ctx context.Context) error {
+	if s.roleID == "" || s.secretID == "" {
+		return ErrVaultNotConfigured
+	}
+	body, err := json.Marshal(map[string]string{
+		"role_id":   s.roleID,
+		"secret_id": s.secretID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := s.do(ctx, http.MethodPost, "auth/approle/login", body, &resp); err != nil {
+		return err
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("vault: approle login returned no token")
+	}
+	s.token = resp.Auth.ClientToken
+	return nil
+}
+
+func (s *vaultSession) do(ctx context.Context, method, path string, body []byte, out any) error {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.addr+"/v1/"+path, r)
+	if err != nil {
+		return err
+	}
+	if s.token != "" {
+		req.Header.Set("X-Vault-Token", s.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		verr := &VaultError{Path: path, StatusCode: resp.StatusCode}
+		var payload struct {
+			Errors []string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&payload) == nil {
+			verr.Errors = payload.Errors
+		}
+		return verr
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	return dec.Decode(out)
+}
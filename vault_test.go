@@ -0,0 +1,178 @@
+package envx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newVaultServer(t *testing.T, reads *int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding login body: %v", err)
+		}
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"approle-token"}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/app", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(reads, 1)
+		switch r.Header.Get("X-Vault-Token") {
+		case "root", "approle-token":
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"password":"s3cret","port":5432,"user":"app"},"metadata":{"version":3}}}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type vaultConfig struct {
+	Password string `envx:"DB_PASSWORD"`
+	Port     int    `envx:"DB_PORT"`
+	User     string `envx:"DB_USER"`
+}
+
+var vaultEnv = map[string]string{
+	"DB_PASSWORD": "ref+vault://secret/data/app#password",
+	"DB_PORT":     "ref+vault://secret/data/app#port",
+	"DB_USER":     "ref+vault:///secret/data/app/#user",
+}
+
+func TestVaultResolverToken(t *testing.T) {
+	var reads int32
+	srv := newVaultServer(t, &reads)
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	config := &vaultConfig{}
+	err := ProcessWith("", config, WithLookuper(MapSource(vaultEnv)), WithReferences())
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Password != "s3cret" || config.Port != 5432 || config.User != "app" {
+		t.Errorf("ProcessWith() = %+v", config)
+	}
+	if reads != 1 {
+		t.Errorf("Expected one read per path per call, got %d", reads)
+	}
+
+	if err := ProcessWith("", config, WithLookuper(MapSource(vaultEnv)), WithReferences()); err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if reads != 2 {
+		t.Errorf("Expected cache to be scoped to one call, got %d reads", reads)
+	}
+}
+
+func TestVaultResolverAppRole(t *testing.T) {
+	var reads int32
+	srv := newVaultServer(t, &reads)
+
+	config := &vaultConfig{}
+	err := ProcessWith("", config,
+		WithLookuper(MapSource(vaultEnv)),
+		WithResolver("vault", &VaultResolver{Addr: srv.URL, RoleID: "role", SecretID: "secret"}),
+	)
+	if err != nil {
+		t.Fatalf("ProcessWith() unexpected error: %v", err)
+	}
+	if config.Password != "s3cret" {
+		t.Errorf("Expected Password 's3cret', got %q", config.Password)
+	}
+}
+
+func TestVaultResolverErrors(t *testing.T) {
+	var reads int32
+	srv := newVaultServer(t, &reads)
+
+	tests := []struct {
+		name     string
+		resolver *VaultResolver
+		ref      string
+		status   int
+		msg      string
+	}{
+		{
+			name:     "permission denied",
+			resolver: &VaultResolver{Addr: srv.URL, Token: "bad"},
+			ref:      "ref+vault://secret/data/app#password",
+			status:   http.StatusForbidden,
+			msg:      "permission denied",
+		},
+		{
+			name:     "not found",
+			resolver: &VaultResolver{Addr: srv.URL, Token: "root"},
+			ref:      "ref+vault://secret/data/other#password",
+			status:   http.StatusNotFound,
+		},
+		{
+			name:     "bad approle",
+			resolver: &VaultResolver{Addr: srv.URL, RoleID: "role", SecretID: "wrong"},
+			ref:      "ref+vault://secret/data/app#password",
+			status:   http.StatusBadRequest,
+			msg:      "invalid role or secret ID",
+		},
+		{
+			name:     "missing key",
+			resolver: &VaultResolver{Addr: srv.URL, Token: "root"},
+			ref:      "ref+vault://secret/data/app#nope",
+			msg:      `has no key "nope"`,
+		},
+		{
+			name:     "missing fragment",
+			resolver: &VaultResolver{Addr: srv.URL, Token: "root"},
+			ref:      "ref+vault://secret/data/app",
+			msg:      "must be <path>#<key>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VAULT_TOKEN", "")
+			err := ProcessWith("", &vaultConfig{},
+				WithLookuper(MapSource(map[string]string{"DB_PASSWORD": tt.ref})),
+				WithResolver("vault", tt.resolver),
+			)
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.KeyName != "DB_PASSWORD" {
+				t.Fatalf("Expected ParseError on DB_PASSWORD, got %v", err)
+			}
+			if tt.status != 0 {
+				var verr *VaultError
+				if !errors.As(err, &verr) || verr.StatusCode != tt.status {
+					t.Errorf("Expected VaultError %d, got %v", tt.status, err)
+				}
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Error %q does not contain %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestVaultResolverNotConfigured(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+	err := ProcessWith("", &vaultConfig{},
+		WithLookuper(MapSource(map[string]string{"DB_PASSWORD": "ref+vault://secret/data/app#password"})),
+		WithReferences(),
+	)
+	if !errors.Is(err, ErrVaultNotConfigured) {
+		t.Errorf("Expected ErrVaultNotConfigured, got %v", err)
+	}
+}